@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,6 +10,9 @@ import (
 	"time"
 
 	"github.com/ITU-BeeHub/BeeHub-backend/addDropBot/documents" // Buradaki dosya yolunu proje yapınıza göre düzenleyin
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/itulogin"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/vault"
 
 	"github.com/kardianos/service"
 )
@@ -18,6 +22,16 @@ const (
 	availabilityURL = "https://www.sis.itu.edu.tr/TR/ogrenci/ders-programi/ders-kontenjan.php?crn="
 
 	checkInterval = 16 * time.Minute
+
+	// tokenRefreshAhead is how long before its expiry the Kepler JWT is renewed.
+	tokenRefreshAhead = 5 * time.Minute
+
+	// Encrypted credential vault kept in the Documents folder, its key is kept apart
+	// in the user's config directory. Older versions kept the key next to the vault.
+	vaultFileName      = ".credentials.vault"
+	vaultKeyFileName   = "bot.key"
+	oldVaultKeyFile    = ".beehub.key"
+	vaultCredentialsID = "itu"
 )
 
 type Course struct {
@@ -28,9 +42,6 @@ type Course struct {
 	Enrolement string `json:"enrolement"`
 }
 
-var Token string = ""
-var Duration time.Time
-
 type program struct{}
 
 var logger service.Logger
@@ -58,25 +69,39 @@ func (p *program) run() {
 	if err != nil {
 		log.Fatalf("Failed to read CRNs from file: %v", err)
 	}
-	credentialsFilePath := filepath.Join(documentsDir, ".credentials.txt")
-	email, password, err := readCredentialsFromFile(credentialsFilePath)
+	credentialVault, err := openVault(documentsDir)
 
 	fmt.Println("CRNs:", crns)
 	if err != nil {
-		log.Fatalf("Error reading CRNs from file: %v", err)
+		log.Fatalf("Error opening credential vault: %v", err)
 
 	}
 	allCourses := []Course{}
 
-	if Token == "" || time.Since(Duration) > 5*time.Hour {
-		token, err := LoginService(email, password)
+	keplerBaseURL := os.Getenv("KEPLER_BASE_URL")
+	if keplerBaseURL == "" {
+		keplerBaseURL = kepler.DefaultBaseURL
+	}
+	keplerClient := kepler.NewHTTPClient(keplerBaseURL)
+
+	// Credentials are decrypted from the vault only when a login is needed
+	tokens := kepler.NewTokenKeeper("", tokenRefreshAhead, func() (string, error) {
+		credentials, err := credentialVault.Get(vaultCredentialsID)
 		if err != nil {
-			log.Fatalf("Error logging in: %v", err)
+			return "", err
 		}
-		Token = token
-		Duration = time.Now()
+		return keplerClient.Login(credentials.Username, credentials.Password)
+	})
+	if _, err := tokens.Token(); err != nil {
+		if errors.Is(err, itulogin.ErrInvalidCredentials) {
+			log.Fatalf("ITU rejected the stored credentials, update them and restart the service")
+		}
+		log.Fatalf("Error logging in: %v", err)
 	}
-	resp, err := SendCourseRequestsToCRNs(crns)
+	tokens.Start()
+	defer tokens.Stop()
+
+	resp, err := SendCourseRequestsToCRNs(keplerClient, tokens, crns)
 	if err != nil {
 		log.Fatalf("Error sending course requests: %v", err)
 	}
@@ -114,15 +139,7 @@ func (p *program) run() {
 			log.Fatal(err)
 		}
 		log.Printf("Available courses: %v", availableCourses)
-		if Token == "" || time.Since(Duration) > 5*time.Hour {
-			token, err := LoginService(email, password)
-			if err != nil {
-				log.Fatalf("Error logging in: %v", err)
-			}
-			Token = token
-			Duration = time.Now()
-		}
-		resp, err := SendCourseRequests(availableCourses)
+		resp, err := SendCourseRequests(keplerClient, tokens, availableCourses)
 		if err != nil {
 			log.Fatalf("Error sending course requests: %v", err)
 		}
@@ -195,6 +212,74 @@ func readCRNsFromFile(filePath string) ([]string, error) {
 	return crns, nil
 }
 
+// openVault opens the encrypted credential vault in the Documents folder.
+// A plaintext .credentials.txt left from older versions is moved into the vault and removed.
+func openVault(documentsDir string) (*vault.Vault, error) {
+	keyFile, err := vaultKeyFile(documentsDir)
+	if err != nil {
+		return nil, err
+	}
+	credentialVault, err := vault.Open(
+		filepath.Join(documentsDir, vaultFileName),
+		os.Getenv("BEEHUB_VAULT_PASSPHRASE"),
+		keyFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintextPath := filepath.Join(documentsDir, ".credentials.txt")
+	if _, err := os.Stat(plaintextPath); err == nil {
+		email, password, err := readCredentialsFromFile(plaintextPath)
+		if err != nil {
+			return nil, err
+		}
+		err = credentialVault.Put(vaultCredentialsID, vault.Credentials{Username: email, Password: password})
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Remove(plaintextPath); err != nil {
+			log.Printf("Failed to remove plaintext credentials file: %v", err)
+		}
+		log.Println("Moved credentials into the vault")
+	}
+
+	return credentialVault, nil
+}
+
+// vaultKeyFile returns the key file in the user's config directory, moving a key
+// older versions left next to the vault there.
+func vaultKeyFile(documentsDir string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	keyFile := filepath.Join(configDir, "beehub", vaultKeyFileName)
+
+	oldKeyFile := filepath.Join(documentsDir, oldVaultKeyFile)
+	key, err := os.ReadFile(oldKeyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return keyFile, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(keyFile); err == nil {
+		return "", fmt.Errorf("vault keys found in both %s and %s, remove the one that does not open the vault", oldKeyFile, keyFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Remove(oldKeyFile); err != nil {
+		log.Printf("Failed to remove the old vault key: %v", err)
+	}
+	log.Println("Moved the vault key to", keyFile)
+	return keyFile, nil
+}
+
 func readCredentialsFromFile(filepath string) (string, string, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
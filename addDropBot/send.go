@@ -1,80 +1,32 @@
 package main
 
 import (
-	"encoding/json"
-
-	"github.com/go-resty/resty/v2"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
 )
 
-const apiURL = "https://obs.itu.edu.tr/api/ders-kayit/v21"
-
-type Result struct {
-	CRN        string `json:"crn"`
-	ResultCode string `json:"resultCode"`
-}
-
-type Response struct {
-	ECRNResultList []Result `json:"ecrnResultList"`
-	SCRNResultList []Result `json:"scrnResultList"`
-}
-
-func SendCourseRequests(courses []Course) (*Response, error) {
-	client := resty.New()
-	headers := map[string]string{
-		"accept":        "application/json, text/plain, */*",
-		"authorization": "Bearer  " + Token,
-		"origin":        "https://obs.itu.edu.tr",
-		"referer":       "https://obs.itu.edu.tr/ogrenci/DersKayitIslemleri/DersKayit",
-	}
+type Response = kepler.RegisterResponse
 
+func SendCourseRequests(client kepler.KeplerClient, tokens *kepler.TokenKeeper, courses []Course) (*Response, error) {
 	crns := []string{}
 	for _, course := range courses {
 		crns = append(crns, course.CRN)
 	}
 
-	payload := map[string]interface{}{
-		"ECRN": crns,       // Example CRNs to be added
-		"SCRN": []string{}, // Example CRNs to be deleted
-	}
-
-	resp, err := client.R().SetHeaders(headers).SetBody(payload).Post(apiURL)
-
-	if err != nil {
-		return nil, err
-	}
-	var response Response
-	err = json.Unmarshal(resp.Body(), &response)
-	if err != nil {
-		return nil, err
-	}
-
-	return &response, nil
+	return SendCourseRequestsToCRNs(client, tokens, crns)
 }
 
-func SendCourseRequestsToCRNs(crns []string) (*Response, error) {
-	client := resty.New()
-	headers := map[string]string{
-		"accept":        "application/json, text/plain, */*",
-		"authorization": "Bearer  " + Token,
-		"origin":        "https://obs.itu.edu.tr",
-		"referer":       "https://obs.itu.edu.tr/ogrenci/DersKayitIslemleri/DersKayit",
-	}
-
-	payload := map[string]interface{}{
-		"ECRN": crns,       // Example CRNs to be added
-		"SCRN": []string{}, // Example CRNs to be deleted
-	}
-
-	resp, err := client.R().SetHeaders(headers).SetBody(payload).Post(apiURL)
-
-	if err != nil {
-		return nil, err
-	}
-	var response Response
-	err = json.Unmarshal(resp.Body(), &response)
+// SendCourseRequestsToCRNs asks Kepler to add the given CRNs.
+// A request rejected with 401 is retried once with a renewed token.
+func SendCourseRequestsToCRNs(client kepler.KeplerClient, tokens *kepler.TokenKeeper, crns []string) (*Response, error) {
+	var response *Response
+	err := tokens.Do(func(token string) error {
+		var err error
+		response, err = client.Register(token, crns, nil)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, nil
+	return response, nil
 }
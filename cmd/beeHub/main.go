@@ -2,20 +2,28 @@ package main
 
 import (
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
+	"time"
 
 	_ "github.com/ITU-BeeHub/BeeHub-backend/docs"
 	auth "github.com/ITU-BeeHub/BeeHub-backend/internal/auth"
 
 	beepicker "github.com/ITU-BeeHub/BeeHub-backend/internal/beePicker"
-	"github.com/ITU-BeeHub/BeeHub-backend/pkg"
-	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/config"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/ratelimit"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/token"
 	utils "github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/vault"
 
 	cors "github.com/gin-contrib/cors"
 	gin "github.com/gin-gonic/gin"
@@ -68,23 +76,36 @@ func fetchBackendVersion() {
 	fmt.Printf("Fetched backend version: %s\n", BackendVersion)
 }
 
+// @title BeeHub Ders Seçim Botu API
+// @version 1.0
+// @description Bu, BeeHub Ders Seçim Botu için API dokümantasyonudur.
+// @host localhost:8080
+// @BasePath /
 func main() {
 	// Fetch the backend version on startup
 	fetchBackendVersion()
 
-	personManager := pkg.NewPersonManager()
-	person := &models.Person{}
-	personManager.UpdatePerson(person)
 	utils.LoadEnvVariables()
+	cfg := config.Load()
 
-	r := gin.Default()
+	sessions := session.NewManager(cfg.SessionTTL, cfg.SessionIdleTimeout, cfg.MaxSessions)
+	sessions.StartJanitor(time.Minute)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	r.Use(gin.Logger(), apperr.RequestID(), apperr.Recovery())
+	r.NoRoute(func(c *gin.Context) {
+		apperr.Respond(c, apperr.ErrNotFound)
+	})
 
 	// CORS configuration
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"}, // Adjust this to your frontend's URL
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-None-Match", apperr.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "ETag", "Age", apperr.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * 60 * 60, // 12 hours
 	}))
@@ -101,23 +122,88 @@ func main() {
 		fmt.Println("Swagger is disabled")
 	}
 
-	authService := auth.NewService(personManager)
+	// Sessions do not survive a restart, so neither do the credentials needed to renew their Kepler tokens
+	credentials, err := vault.NewMemory()
+	if err != nil {
+		log.Fatalf("Failed to open credential vault: %v", err)
+	}
+
+	keplerClient := kepler.NewHTTPClient(cfg.KeplerBaseURL)
+
+	limits := ratelimit.NewMemoryStore()
+	limits.StartJanitor(time.Minute)
+	loginLimiters := auth.LoginLimiters{
+		IP: ratelimit.NewLimiter(limits, "login-ip", ratelimit.Policy{
+			Attempts:    cfg.LoginIPAttempts,
+			Window:      cfg.LoginWindow,
+			MaxFailures: cfg.LoginIPFailures,
+			Lockout:     cfg.LoginLockout,
+			MaxLockout:  cfg.LoginMaxLockout,
+		}),
+		Username: ratelimit.NewLimiter(limits, "login-user", ratelimit.Policy{
+			Attempts:    cfg.LoginUserAttempts,
+			Window:      cfg.LoginWindow,
+			MaxFailures: cfg.LoginUserFailures,
+			Lockout:     cfg.LoginLockout,
+			MaxLockout:  cfg.LoginMaxLockout,
+		}),
+	}
+
+	signer := token.NewSigner(cfg.TokenSecret, cfg.TokenTTL)
+	authService := auth.NewService(keplerClient, sessions, signer, credentials, loginLimiters, cfg.KeplerRefreshAhead, cfg.ProfileCacheTTL, cfg.KeplerTermID)
 	authHandler := auth.NewHandler(authService)
 
 	r.POST("/auth/login", authHandler.LoginHandler)
+	r.POST("/auth/refresh", authHandler.RefreshHandler)
 
 	// beePicker routes
-	beePickerService := beepicker.NewService(personManager)
+	beePickerService, err := beepicker.NewService(keplerClient, beepicker.CatalogConfig{
+		Sources:           cfg.CourseSources,
+		LocalDir:          cfg.CourseLocalDir,
+		Dir:               cfg.CatalogDir,
+		Workers:           cfg.CatalogWorkers,
+		RequestTimeout:    cfg.CatalogRequestTimeout,
+		Retries:           cfg.CatalogRetries,
+		MaxFailedBranches: cfg.CatalogMaxFailedBranches,
+		GitHubToken:       cfg.GitHubToken,
+	})
+	if err != nil {
+		log.Fatalf("Invalid course catalog configuration: %v", err)
+	}
 	beePickerHandler := beepicker.NewHandler(beePickerService)
 
 	r.GET("/beePicker/courses", beePickerHandler.CourseHandler)
+	r.GET("/beePicker/search", beePickerHandler.SearchHandler)
+	r.GET("/beePicker/terms", beePickerHandler.TermsHandler)
+	r.GET("/beePicker/changes", beePickerHandler.ChangesHandler)
+	r.GET("/beePicker/changes/events", beePickerHandler.ChangeEventsHandler)
+	r.POST("/beePicker/conflicts", beePickerHandler.ConflictsHandler)
 
 	// Protected routes
 	protected := r.Group("/")
 	protected.Use(auth.AuthMiddleware(authService))
 	{
+		protected.POST("/auth/logout", authHandler.LogoutHandler)
 		protected.GET("/auth/profile", authHandler.ProfileHandler)
+		protected.GET(auth.PhotoURL, authHandler.PhotoHandler)
+		protected.GET("/auth/transcript", authHandler.TranscriptHandler)
+		protected.GET("/auth/terms", authHandler.TermsHandler)
+		protected.GET("/auth/academic-status", authHandler.AcademicStatusHandler)
 		protected.POST("/beePicker/pick", beePickerHandler.PickHandler)
+		protected.POST("/beePicker/pick/:scheduleName", beePickerHandler.PickScheduleHandler)
+		protected.POST("/beePicker/generate", beePickerHandler.GenerateHandler)
+
+		protected.GET("/schedules", beePickerHandler.ListSchedulesHandler)
+		protected.POST("/schedules", beePickerHandler.CreateScheduleHandler)
+		protected.GET("/schedules/:name", beePickerHandler.GetScheduleHandler)
+		protected.PUT("/schedules/:name", beePickerHandler.UpdateScheduleHandler)
+		protected.PATCH("/schedules/:name", beePickerHandler.RenameScheduleHandler)
+		protected.DELETE("/schedules/:name", beePickerHandler.DeleteScheduleHandler)
+	}
+
+	// Runtime metrics, e.g. the course catalog refreshes, only on the debug listener
+	if cfg.DebugAddr != "" {
+		go serveDebug(cfg.DebugAddr)
 	}
 
 	r.GET("/start-service", startService)
@@ -126,11 +212,21 @@ func main() {
 	r.Run(":8080")
 }
 
+// serveDebug serves /debug/vars on addr, apart from the API so that it is not reachable from outside.
+func serveDebug(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Debug listener on %s stopped: %v", addr, err)
+	}
+}
+
 // @Summary Start the BeeHubBot process
 // @Description Starts the BeeHubBot process as a background process
 // @Tags Service
 // @Success 200 {object} map[string]string "Process started"
-// @Failure 500 {object} map[string]string "Error starting process"
+// @Failure 500 {object} apperr.Envelope "Error starting process"
+// @Failure 501 {object} apperr.Envelope "Unsupported OS"
 // @Router /start-service [get]
 // startService sets the service startup type to automatic and starts the service.
 func startService(c *gin.Context) {
@@ -138,12 +234,12 @@ func startService(c *gin.Context) {
 		cmd := exec.Command("cmd", "/C", "startasadmin.bat")
 		err := cmd.Run()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error starting service: %v", err)})
+			apperr.Respond(c, apperr.ErrInternal.Wrap(fmt.Errorf("error starting service: %w", err)))
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Service started and set to automatic"})
 	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unsupported OS"})
+		apperr.Respond(c, apperr.ErrUnsupported)
 	}
 }
 
@@ -151,7 +247,8 @@ func startService(c *gin.Context) {
 // @Description Stops the BeeHubBot process
 // @Tags Service
 // @Success 200 {object} map[string]string "Process stopped"
-// @Failure 500 {object} map[string]string "Error stopping process"
+// @Failure 500 {object} apperr.Envelope "Error stopping process"
+// @Failure 501 {object} apperr.Envelope "Unsupported OS"
 // @Router /stop-service [get]
 // stopService stops the service and sets the startup type to manual.
 func stopService(c *gin.Context) {
@@ -159,11 +256,11 @@ func stopService(c *gin.Context) {
 		cmd := exec.Command("cmd", "/C", "stopasadmin.bat")
 		err := cmd.Run()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error stopping service: %v", err)})
+			apperr.Respond(c, apperr.ErrInternal.Wrap(fmt.Errorf("error stopping service: %w", err)))
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Service stopped and set to manual"})
 	} else {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unsupported OS"})
+		apperr.Respond(c, apperr.ErrUnsupported)
 	}
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A 1x1 transparent PNG served as the student photo
+const photoBase64 = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+// maxCRNs is the number of CRNs Kepler accepts in one ders-kayit call.
+const maxCRNs = 12
+
+type api struct {
+	store *scenarioStore
+}
+
+// latency delays every response by the latency of the scenario.
+func (a *api) latency(c *gin.Context) {
+	if latency := time.Duration(a.store.get().Latency); latency > 0 {
+		time.Sleep(latency)
+	}
+	c.Next()
+}
+
+func (a *api) personalInfo(c *gin.Context) {
+	student := a.store.get().Student
+	c.JSON(http.StatusOK, gin.H{
+		"kisiselBilgiler": gin.H{
+			"adSoyad":         student.FullName,
+			"ogrenciNo":       student.StudentNumber,
+			"ePosta":          student.Email,
+			"cinsiyet":        student.Gender,
+			"fakulteEN":       student.Faculty,
+			"bolumAdiEN":      student.Department,
+			"programAdiEN":    student.Program,
+			"danismanAdSoyad": student.Advisor,
+		},
+	})
+}
+
+func (a *api) photo(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"base64Fotograf": photoBase64})
+}
+
+func (a *api) terms(c *gin.Context) {
+	terms := []gin.H{}
+	for _, term := range a.store.get().Terms {
+		terms = append(terms, gin.H{
+			"akademikDonemId":   term.ID,
+			"akademikDonemKodu": term.Code,
+			"akademikDonemAdi":  term.Name,
+			"guncelDonemMi":     term.Current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"donemListesi": terms})
+}
+
+// academicStatus answers for the terms of the scenario only, like Kepler does for
+// term IDs the student was not enrolled in.
+func (a *api) academicStatus(c *gin.Context) {
+	scenario := a.store.get()
+	if !slices.ContainsFunc(scenario.Terms, func(term Term) bool { return fmt.Sprint(term.ID) == c.Param("id") }) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	student := scenario.Student
+	c.JSON(http.StatusOK, gin.H{
+		"akademikDurum": gin.H{
+			"sinifSeviye":        student.Class,
+			"genelNotOrtalamasi": student.GPA,
+		},
+	})
+}
+
+type registerRequest struct {
+	ECRN []string `json:"ECRN"`
+	SCRN []string `json:"SCRN"`
+}
+
+type crnResult struct {
+	CRN          string  `json:"crn"`
+	OperationFin bool    `json:"operationFin"`
+	StatusCode   int     `json:"statusCode"`
+	ResultCode   string  `json:"resultCode"`
+	ResultData   *string `json:"resultData"`
+}
+
+// register emulates ders-kayit/v21. The outcome of every CRN is decided by the scenario.
+func (a *api) register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	var step Step
+	var ongoing bool
+	a.store.update(func(scenario *Scenario) {
+		if len(scenario.Script) > 0 {
+			step = scenario.Script[0]
+			scenario.Script = scenario.Script[1:]
+		}
+		if scenario.OngoingTransaction > 0 {
+			ongoing = true
+			scenario.OngoingTransaction--
+		}
+	})
+
+	if step.Latency > 0 {
+		time.Sleep(time.Duration(step.Latency))
+	}
+	if step.Status != 0 {
+		c.Status(step.Status)
+		return
+	}
+
+	ecrnResults := []crnResult{}
+	scrnResults := []crnResult{}
+	a.store.update(func(scenario *Scenario) {
+		tooMany := len(req.ECRN)+len(req.SCRN) > maxCRNs
+
+		for _, crn := range req.ECRN {
+			code := step.ResultCode
+			switch {
+			case code != "":
+			case tooMany:
+				code = "VAL15"
+			case ongoing:
+				code = "VAL16"
+			case scenario.TimeHold:
+				code = "VAL02"
+			case slices.Contains(scenario.Registered, crn):
+				code = "VAL03"
+			case slices.Contains(scenario.QuotaFull, crn) || slices.Contains(scenario.QuotaFull, "*"):
+				code = "VAL06"
+			default:
+				code = "successResult"
+				scenario.Registered = append(scenario.Registered, crn)
+			}
+			ecrnResults = append(ecrnResults, newResult(crn, code))
+		}
+
+		for _, crn := range req.SCRN {
+			code := step.ResultCode
+			switch {
+			case code != "":
+			case tooMany:
+				code = "VAL15"
+			case ongoing:
+				code = "VAL16"
+			case !slices.Contains(scenario.Registered, crn):
+				code = "VAL10"
+			default:
+				code = "successResult"
+				scenario.Registered = slices.DeleteFunc(scenario.Registered, func(registered string) bool {
+					return registered == crn
+				})
+			}
+			scrnResults = append(scrnResults, newResult(crn, code))
+		}
+	})
+
+	c.JSON(http.StatusOK, gin.H{"ecrnResultList": ecrnResults, "scrnResultList": scrnResults})
+}
+
+func newResult(crn, code string) crnResult {
+	result := crnResult{CRN: crn, ResultCode: code, StatusCode: 1}
+	if code == "successResult" {
+		result.StatusCode = 0
+		result.OperationFin = true
+	}
+	return result
+}
+
+// getScenario returns the active scenario, including the consumed script and registered CRNs.
+func (a *api) getScenario(c *gin.Context) {
+	c.JSON(http.StatusOK, a.store.get())
+}
+
+// putScenario replaces the active scenario, fields left out take their default values.
+func (a *api) putScenario(c *gin.Context) {
+	scenario := defaultScenario()
+	if err := c.ShouldBindJSON(&scenario); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a.store.set(scenario)
+	c.JSON(http.StatusOK, scenario)
+}
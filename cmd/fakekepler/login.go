@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authCookie is the cookie the fake SSO sets after a successful login.
+const authCookie = ".ASPXAUTH"
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>İTÜ Giriş</title></head>
+<body>
+<form method="post" action="/giris?subSessionId={{.SubSessionID}}" id="form1">
+<input type="hidden" name="__VIEWSTATE" id="__VIEWSTATE" value="{{.ViewState}}" />
+<input type="hidden" name="__VIEWSTATEGENERATOR" id="__VIEWSTATEGENERATOR" value="{{.ViewStateGenerator}}" />
+<input type="hidden" name="__EVENTVALIDATION" id="__EVENTVALIDATION" value="{{.EventValidation}}" />
+<input type="hidden" name="ctl00$ContentPlaceHolder1$hfAppName" value="Öğrenci Bilgi Sistemi" />
+{{if .Error}}<span class="error">{{.Error}}</span>{{end}}
+<input name="ctl00$ContentPlaceHolder1$tbUserName" type="text" />
+<input name="ctl00$ContentPlaceHolder1$tbPassword" type="password" />
+<input type="submit" name="ctl00$ContentPlaceHolder1$btnLogin" value="Giriş / Login" />
+</form>
+</body>
+</html>`))
+
+// viewStateGenerator is constant per page in ASP.NET.
+const viewStateGenerator = "C2EE9ABB"
+
+// sso emulates the ASP.NET login form of ITU and the JWT endpoint of Kepler.
+type sso struct {
+	username string
+	password string
+	secret   []byte
+	store    *scenarioStore
+
+	mu          sync.Mutex
+	viewStates  map[string]string // __VIEWSTATE -> __EVENTVALIDATION
+	authCookies map[string]string // cookie -> username
+}
+
+func newSSO(username, password string, store *scenarioStore) *sso {
+	return &sso{
+		username:    username,
+		password:    password,
+		secret:      []byte(randomString(32)),
+		store:       store,
+		viewStates:  make(map[string]string),
+		authCookies: make(map[string]string),
+	}
+}
+
+// entry redirects to the SSO like obs.itu.edu.tr does.
+func (s *sso) entry(c *gin.Context) {
+	c.Redirect(http.StatusFound, "/giris?subSessionId="+randomString(12))
+}
+
+// redirect forwards to the login page, the login form is posted back to this URL.
+func (s *sso) redirect(c *gin.Context) {
+	c.Redirect(http.StatusFound, "/giris/Login.aspx?subSessionId="+c.Query("subSessionId"))
+}
+
+func (s *sso) loginForm(c *gin.Context) {
+	s.renderForm(c, c.Query("subSessionId"), "")
+}
+
+func (s *sso) renderForm(c *gin.Context, subSessionID, message string) {
+	viewState := randomString(48)
+	eventValidation := randomString(32)
+	s.mu.Lock()
+	s.viewStates[viewState] = eventValidation
+	s.mu.Unlock()
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	loginPage.Execute(c.Writer, map[string]string{
+		"SubSessionID":       subSessionID,
+		"ViewState":          viewState,
+		"ViewStateGenerator": viewStateGenerator,
+		"EventValidation":    eventValidation,
+		"Error":              message,
+	})
+}
+
+// submit checks the posted form like ASP.NET would: the hidden fields must come
+// from a rendered form and may only be used once.
+func (s *sso) submit(c *gin.Context) {
+	viewState := c.PostForm("__VIEWSTATE")
+	s.mu.Lock()
+	eventValidation, ok := s.viewStates[viewState]
+	delete(s.viewStates, viewState)
+	s.mu.Unlock()
+
+	if !ok || eventValidation != c.PostForm("__EVENTVALIDATION") ||
+		c.PostForm("__VIEWSTATEGENERATOR") != viewStateGenerator {
+		c.String(http.StatusInternalServerError, "Validation of viewstate MAC failed.")
+		return
+	}
+
+	username := c.PostForm("ctl00$ContentPlaceHolder1$tbUserName")
+	password := c.PostForm("ctl00$ContentPlaceHolder1$tbPassword")
+	if username != s.username || password != s.password {
+		s.renderForm(c, c.Query("subSessionId"), "Kullanıcı adı veya şifre hatalı.")
+		return
+	}
+
+	cookie := randomString(32)
+	s.mu.Lock()
+	s.authCookies[cookie] = username
+	s.mu.Unlock()
+	c.SetCookie(authCookie, cookie, 0, "/", "", false, true)
+	c.Redirect(http.StatusFound, "/ogrenci/")
+}
+
+func (s *sso) home(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, "<!DOCTYPE html><html><body><div id=\"app\">Öğrenci Bilgi Sistemi</div></body></html>")
+}
+
+// jwt returns a token for a logged in browser and the login page for everyone else.
+func (s *sso) jwt(c *gin.Context) {
+	cookie, _ := c.Cookie(authCookie)
+	s.mu.Lock()
+	username, ok := s.authCookies[cookie]
+	s.mu.Unlock()
+	if !ok {
+		c.Redirect(http.StatusFound, "/giris/Login.aspx")
+		return
+	}
+	ttl := time.Duration(s.store.get().TokenTTL)
+	c.String(http.StatusOK, s.issue(username, ttl))
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (s *sso) issue(username string, ttl time.Duration) string {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims{Subject: username, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned)
+}
+
+func (s *sso) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// requireToken answers 401 unless the request carries a valid, unexpired JWT.
+func (s *sso) requireToken(c *gin.Context) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer"))
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || !hmac.Equal([]byte(s.sign(parts[0]+"."+parts[1])), []byte(parts[2])) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	var tokenClaims claims
+	if err != nil || json.Unmarshal(payload, &tokenClaims) != nil || time.Now().Unix() > tokenClaims.ExpiresAt {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Next()
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)[:n]
+}
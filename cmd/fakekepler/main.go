@@ -0,0 +1,65 @@
+// Command fakekepler is a local stand-in for obs.itu.edu.tr (Kepler) and the ITU SSO.
+//
+// It emulates the parts BeeHub talks to: the ASP.NET login form, the JWT endpoint,
+// the student API, the transcript preview and ders-kayit. Its behaviour is scripted
+// with a scenario file (see Scenario) that can be replaced at runtime with
+// PUT /_fake/scenario.
+//
+// Point the backend at it with KEPLER_BASE_URL=http://localhost:8081.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "address to listen on")
+	username := flag.String("user", "test", "username accepted by the login form")
+	password := flag.String("password", "test", "password accepted by the login form")
+	scenarioPath := flag.String("scenario", "", "JSON scenario file")
+	flag.Parse()
+
+	scenario, err := loadScenario(*scenarioPath)
+	if err != nil {
+		log.Fatalf("Failed to load scenario: %v", err)
+	}
+	store := &scenarioStore{}
+	store.set(scenario)
+
+	sso := newSSO(*username, *password, store)
+	api := &api{store: store}
+
+	r := gin.Default()
+
+	// Scenario control
+	r.GET("/_fake/scenario", api.getScenario)
+	r.PUT("/_fake/scenario", api.putScenario)
+
+	r.Use(api.latency)
+
+	// ITU SSO login flow
+	r.GET("/", sso.entry)
+	r.GET("/giris", sso.redirect)
+	r.POST("/giris", sso.submit)
+	r.GET("/giris/Login.aspx", sso.loginForm)
+	r.GET("/ogrenci/", sso.home)
+	r.GET("/ogrenci/auth/jwt", sso.jwt)
+
+	// Kepler API
+	protected := r.Group("/api", sso.requireToken)
+	{
+		protected.GET("/ogrenci/KisiselBilgiler", api.personalInfo)
+		protected.GET("/ogrenci/OgrenciFotograf", api.photo)
+		protected.GET("/ogrenci/AkademikDurum/DonemListesi", api.terms)
+		protected.GET("/ogrenci/AkademikDurum/:id", api.academicStatus)
+		protected.GET("/ogrenci/Belgeler/TranskriptIngilizceOnizleme", api.transcript)
+		protected.POST("/ders-kayit/v21", api.register)
+	}
+
+	fmt.Printf("Fake Kepler listening on %s (user %q)\n", *addr, *username)
+	r.Run(*addr)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Duration is a time.Duration written as a string ("500ms", "1h") in scenario files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Student is the person the fake Kepler reports in its API responses.
+type Student struct {
+	FullName      string  `json:"fullName"`
+	StudentNumber string  `json:"studentNumber"`
+	Email         string  `json:"email"`
+	Gender        string  `json:"gender"`
+	Faculty       string  `json:"faculty"`
+	Department    string  `json:"department"`
+	Program       string  `json:"program"`
+	Advisor       string  `json:"advisor"`
+	Class         string  `json:"class"`
+	GPA           float64 `json:"gpa"`
+	// Transcript is rendered by the transcript preview.
+	Transcript []TranscriptTerm `json:"transcript"`
+}
+
+// Term is an academic term listed by DonemListesi.
+type Term struct {
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+// Step overrides the behaviour of one ders-kayit call. Steps are consumed in order.
+type Step struct {
+	// ResultCode is returned for every CRN of the call, e.g. "VAL16".
+	ResultCode string `json:"resultCode,omitempty"`
+	// Status answers the call with this HTTP status instead, e.g. 401 or 503.
+	Status int `json:"status,omitempty"`
+	// Latency delays this call only.
+	Latency Duration `json:"latency,omitempty"`
+}
+
+// Scenario scripts how the fake Kepler behaves.
+type Scenario struct {
+	// Latency delays every response.
+	Latency Duration `json:"latency"`
+	// TokenTTL is the lifetime of the issued JWTs.
+	TokenTTL Duration `json:"tokenTTL"`
+	// QuotaFull lists the CRNs answered with VAL06, "*" matches every CRN.
+	QuotaFull []string `json:"quotaFull"`
+	// TimeHold answers every add with VAL02 (Enrollment Time Hold).
+	TimeHold bool `json:"timeHold"`
+	// OngoingTransaction answers the next N ders-kayit calls with VAL16.
+	OngoingTransaction int `json:"ongoingTransaction"`
+	// Script overrides the next ders-kayit calls one by one.
+	Script []Step `json:"script"`
+	// Student is reported by KisiselBilgiler and AkademikDurum.
+	Student Student `json:"student"`
+	// Terms are listed by DonemListesi, AkademikDurum only answers for their IDs.
+	Terms []Term `json:"terms"`
+	// Registered holds the CRNs the student is registered to.
+	Registered []string `json:"registered"`
+}
+
+func defaultScenario() Scenario {
+	return Scenario{
+		TokenTTL: Duration(time.Hour),
+		Student: Student{
+			FullName:      "Arı Deneme Öğrenci",
+			StudentNumber: "150210999",
+			Email:         "ogrenci@itu.edu.tr",
+			Gender:        "Kadın",
+			Faculty:       "Faculty of Computer and Informatics Engineering",
+			Department:    "Computer Engineering",
+			Program:       "Computer Engineering (%100 English)",
+			Advisor:       "Prof. Dr. Bal Peteği",
+			Class:         "3. Sınıf",
+			GPA:           3.12,
+			Transcript:    defaultTranscript(),
+		},
+		Terms: []Term{
+			{ID: 757, Code: "202310", Name: "2023-2024 Güz Dönemi"},
+			{ID: 758, Code: "202320", Name: "2023-2024 Bahar Dönemi"},
+			{ID: 759, Code: "202410", Name: "2024-2025 Güz Dönemi", Current: true},
+		},
+		Registered: []string{},
+	}
+}
+
+// loadScenario reads a scenario file on top of the defaults.
+func loadScenario(path string) (Scenario, error) {
+	scenario := defaultScenario()
+	if path == "" {
+		return scenario, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario, err
+	}
+	err = json.Unmarshal(data, &scenario)
+	return scenario, err
+}
+
+// scenarioStore guards the active scenario, it can be replaced at runtime.
+type scenarioStore struct {
+	mu       sync.Mutex
+	scenario Scenario
+}
+
+func (s *scenarioStore) get() Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scenario
+}
+
+func (s *scenarioStore) set(scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if scenario.Registered == nil {
+		scenario.Registered = []string{}
+	}
+	s.scenario = scenario
+}
+
+// update runs fn with the scenario locked, for changes that depend on its current state.
+func (s *scenarioStore) update(fn func(*Scenario)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.scenario)
+}
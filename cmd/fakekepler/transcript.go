@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranscriptTerm is one term of the fake transcript.
+type TranscriptTerm struct {
+	Name          string             `json:"name"`
+	Courses       []TranscriptCourse `json:"courses"`
+	TermGPA       float64            `json:"termGpa"`
+	CumulativeGPA float64            `json:"cumulativeGpa"`
+}
+
+type TranscriptCourse struct {
+	Code    string `json:"code"`
+	Title   string `json:"title"`
+	Credits int    `json:"credits"`
+	ECTS    int    `json:"ects"`
+	Grade   string `json:"grade"`
+}
+
+func defaultTranscript() []TranscriptTerm {
+	return []TranscriptTerm{
+		{
+			Name: "2022-2023 Fall",
+			Courses: []TranscriptCourse{
+				{Code: "BLG 101E", Title: "Introduction to Information Systems", Credits: 3, ECTS: 5, Grade: "AA"},
+				{Code: "MAT 103E", Title: "Mathematics I", Credits: 4, ECTS: 6, Grade: "BB"},
+				{Code: "FIZ 101E", Title: "Physics I", Credits: 4, ECTS: 6, Grade: "CB"},
+			},
+			TermGPA:       3.09,
+			CumulativeGPA: 3.09,
+		},
+		{
+			Name: "2022-2023 Spring",
+			Courses: []TranscriptCourse{
+				{Code: "BLG 102E", Title: "Introduction to Scientific and Engineering Computing (C)", Credits: 3, ECTS: 5, Grade: "BA"},
+				{Code: "MAT 104E", Title: "Mathematics II", Credits: 4, ECTS: 6, Grade: "CC"},
+			},
+			TermGPA:       2.93,
+			CumulativeGPA: 3.02,
+		},
+	}
+}
+
+// transcript emulates the English transcript preview, a PDF laid out as a table.
+func (a *api) transcript(c *gin.Context) {
+	student := a.store.get().Student
+	c.Data(http.StatusOK, "application/pdf", transcriptPDF(student))
+}
+
+// transcriptPDF renders the transcript of the student as a one page PDF with a
+// compressed content stream and one text object per table cell, like report generators do.
+func transcriptPDF(student Student) []byte {
+	var content bytes.Buffer
+	y := 800
+	text := func(x int, s string) {
+		fmt.Fprintf(&content, "BT /F1 9 Tf 1 0 0 1 %d %d Tm (%s) Tj ET\n", x, y, pdfEscape(s))
+	}
+	text(50, "ISTANBUL TECHNICAL UNIVERSITY - TRANSCRIPT")
+	y -= 14
+	text(50, "Name: "+student.FullName)
+	y -= 24
+	for _, term := range student.Transcript {
+		text(50, term.Name)
+		y -= 14
+		for _, course := range term.Courses {
+			text(50, course.Code)
+			text(110, course.Title)
+			text(420, fmt.Sprint(course.Credits))
+			text(460, fmt.Sprint(course.ECTS))
+			text(500, course.Grade)
+			y -= 12
+		}
+		text(50, fmt.Sprintf("Term GPA: %.2f", term.TermGPA))
+		text(300, fmt.Sprintf("Cumulative GPA: %.2f", term.CumulativeGPA))
+		y -= 24
+	}
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(content.Bytes())
+	w.Close()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+// pdfEscape escapes a PDF literal string, non Latin-1 characters are replaced.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x80:
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
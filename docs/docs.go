@@ -15,6 +15,39 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/auth/academic-status": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profile"
+                ],
+                "summary": "Returns the class level and GPA of the student in a term",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Term ID from /auth/terms, the current term if omitted",
+                        "name": "term",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AcademicStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown term",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/login": {
             "post": {
                 "consumes": [
@@ -38,6 +71,31 @@ const docTemplate = `{
                         }
                     }
                 ],
+                "responses": {
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many attempts, see the Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Ends the current session",
                 "responses": {}
             }
         },
@@ -56,75 +114,191 @@ const docTemplate = `{
                 "responses": {}
             }
         },
-        "/beePicker/courses": {
+        "/auth/profile/photo": {
             "get": {
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png"
                 ],
                 "tags": [
-                    "BeePicker"
+                    "Profile"
                 ],
-                "summary": "Retrieves courses from the BeePicker.",
-                "responses": {}
+                "summary": "Returns the photo of the student",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Student has no photo",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
             }
         },
-        "/beePicker/pick": {
+        "/auth/refresh": {
             "post": {
-                "consumes": [
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Rotates the BeeHub token",
+                "responses": {}
+            }
+        },
+        "/auth/terms": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "BeePicker"
+                    "Profile"
                 ],
-                "summary": "Picks a course from the kepler.",
+                "summary": "Lists the academic terms of the student",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Term"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/transcript": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Profile"
+                ],
+                "summary": "Returns the transcript of the student",
                 "parameters": [
                     {
-                        "description": "Request body containing the course codes",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "json (default) or pdf for the original document",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/beepicker.pickRequest"
+                            "$ref": "#/definitions/models.Transcript"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Transcript could not be fetched or read",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     }
+                }
+            }
+        },
+        "/beePicker/changes": {
+            "get": {
+                "description": "Reports the added and removed CRNs and the instructor, time, room and capacity changes of the other sections.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BeePicker"
+                ],
+                "summary": "Lists the section changes between two catalog snapshots.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot to compare from, see /beePicker/terms",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Snapshot to compare to, the current catalog by default",
+                        "name": "to",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Picking successful",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.CatalogDiff"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Missing from",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Unknown snapshot",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Course source unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     }
                 }
             }
         },
-        "/beePicker/schedule": {
+        "/beePicker/changes/events": {
             "get": {
+                "description": "Every refresh that changes the catalog sends a \"change\" event holding a models.CatalogDiff.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "BeePicker"
                 ],
-                "summary": "Retrieves schedules from the BeePicker.",
-                "responses": {}
-            },
+                "summary": "Streams catalog changes as server-sent events.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CatalogDiff"
+                        }
+                    }
+                }
+            }
+        },
+        "/beePicker/conflicts": {
             "post": {
+                "description": "Every overlapping pair of meetings is reported, the same check runs before /beePicker/pick.",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,127 +308,1495 @@ const docTemplate = `{
                 "tags": [
                     "BeePicker"
                 ],
-                "summary": "Selects a course from the BeePicker.",
+                "summary": "Checks a set of CRNs for overlapping meetings.",
                 "parameters": [
                     {
-                        "description": "Request body containing the ECRN",
+                        "description": "CRNs to check",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/beepicker.scheduleSaveRequest"
+                            "$ref": "#/definitions/beepicker.conflictsRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Selection successful",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ConflictReport"
                         }
                     },
                     "400": {
                         "description": "Bad request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "502": {
+                        "description": "Course catalog unavailable",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     }
                 }
             }
         },
-        "/start-service": {
+        "/beePicker/courses": {
             "get": {
-                "description": "Starts the BeeHubBot process as a background process",
+                "description": "Without query parameters the whole catalog is returned in one page.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Service"
+                    "BeePicker"
+                ],
+                "summary": "Retrieves courses from the BeePicker.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Branch code, e.g. BLG",
+                        "name": "branch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Course number, e.g. 101E",
+                        "name": "number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CRN",
+                        "name": "crn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of an instructor name",
+                        "name": "instructor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Day name in English or Turkish",
+                        "name": "day",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest start of every meeting (15:04)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest end of every meeting (15:04)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Building code",
+                        "name": "building",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only sections with seats left",
+                        "name": "available",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only sections with at least this many seats left",
+                        "name": "min_seats",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "en",
+                            "tr"
+                        ],
+                        "type": "string",
+                        "description": "Teaching language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "code",
+                            "-code",
+                            "title",
+                            "-title",
+                            "credits",
+                            "-credits",
+                            "seats",
+                            "-seats"
+                        ],
+                        "type": "string",
+                        "description": "Sort key, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 0 returns every course",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Serve the newest snapshot whose name starts with this term",
+                        "name": "term",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Serve this snapshot, see /beePicker/terms",
+                        "name": "snapshot",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Start the BeeHubBot process",
                 "responses": {
                     "200": {
-                        "description": "Process started",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/models.CoursePage"
+                        },
+                        "headers": {
+                            "Age": {
+                                "type": "integer",
+                                "description": "Age of the catalog snapshot in seconds"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Error starting process",
+                    "400": {
+                        "description": "Invalid query",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown term or snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Course catalog unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     }
                 }
             }
         },
-        "/stop-service": {
-            "get": {
-                "description": "Stops the BeeHubBot process",
+        "/beePicker/generate": {
+            "post": {
+                "description": "Every combination of sections without overlapping meetings is ranked by the preferences:\nmeetings before not_before, free days, idle hours between meetings, preferred instructors and full sections.\nWith save the returned schedules are stored in the user's schedules, unless a name is taken.\nA wish list with very many schedules is searched only partly, the result is then truncated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Service"
+                    "BeePicker"
+                ],
+                "summary": "Generates conflict-free schedules from a course wish list.",
+                "parameters": [
+                    {
+                        "description": "Course wish list and preferences",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beepicker.GenerateRequest"
+                        }
+                    }
                 ],
-                "summary": "Stop the BeeHubBot process",
                 "responses": {
                     "200": {
-                        "description": "Process stopped",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.GeneratedSchedules"
                         }
                     },
-                    "500": {
-                        "description": "Error stopping process",
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown course, term or snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "409": {
+                        "description": "A schedule with a saved name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Course catalog unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.LoginRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
+        },
+        "/beePicker/pick": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BeePicker"
+                ],
+                "summary": "Picks a course from the kepler.",
+                "parameters": [
+                    {
+                        "description": "Request body containing the course codes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beepicker.pickRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Picking successful",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "409": {
+                        "description": "The CRNs overlap",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Kepler unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/beePicker/pick/{scheduleName}": {
+            "post": {
+                "description": "Both lists are sent to Kepler in one request, so a course is only dropped together with the one replacing it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BeePicker"
+                ],
+                "summary": "Adds the ECRN and drops the SCRN CRNs of a saved schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "scheduleName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/kepler.CRNResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "The schedule has no CRNs",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "409": {
+                        "description": "The CRNs overlap",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Kepler unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/beePicker/search": {
+            "get": {
+                "description": "Turkish letters, spacing and small typos are ignored, e.g. \"isletim sistemleri\" or \"blg312e\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BeePicker"
+                ],
+                "summary": "Searches courses by code or title.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CourseMatch"
+                            }
+                        },
+                        "headers": {
+                            "Age": {
+                                "type": "integer",
+                                "description": "Age of the catalog snapshot in seconds"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Missing query",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Course catalog unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/beePicker/terms": {
+            "get": {
+                "description": "Every snapshot is a course offering published by the course scraper, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "BeePicker"
+                ],
+                "summary": "Lists the course catalog snapshots.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CatalogSnapshot"
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "The course source keeps no snapshots",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "502": {
+                        "description": "Course source unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Lists the saved schedules.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ScheduleList"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "500": {
+                        "description": "Cannot read the schedules",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Saves a new schedule.",
+                "parameters": [
+                    {
+                        "description": "Schedule name and the CRNs to add (ECRN) and drop (SCRN)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beepicker.scheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "409": {
+                        "description": "A schedule with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Returns a saved schedule by name.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Schedule"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Replaces the CRNs of a saved schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CRNs to add (ECRN) and drop (SCRN)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beepicker.scheduleCRNsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Deletes a saved schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Renames a saved schedule.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beepicker.renameScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "401": {
+                        "description": "Session expired",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "409": {
+                        "description": "A schedule with the new name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/start-service": {
+            "get": {
+                "description": "Starts the BeeHubBot process as a background process",
+                "tags": [
+                    "Service"
+                ],
+                "summary": "Start the BeeHubBot process",
+                "responses": {
+                    "200": {
+                        "description": "Process started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Error starting process",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "501": {
+                        "description": "Unsupported OS",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/stop-service": {
+            "get": {
+                "description": "Stops the BeeHubBot process",
+                "tags": [
+                    "Service"
+                ],
+                "summary": "Stop the BeeHubBot process",
+                "responses": {
+                    "200": {
+                        "description": "Process stopped",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Error stopping process",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    },
+                    "501": {
+                        "description": "Unsupported OS",
+                        "schema": {
+                            "$ref": "#/definitions/apperr.Envelope"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperr.Body": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "apperr.Envelope": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/apperr.Body"
+                }
+            }
+        },
+        "auth.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "beepicker.GenerateRequest": {
+            "type": "object",
+            "required": [
+                "courses"
+            ],
+            "properties": {
+                "courses": {
+                    "description": "Courses are course codes, e.g. \"BLG 312E\". Kepler takes at most 12 CRNs at once.",
+                    "type": "array",
+                    "maxItems": 12,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "description": "Limit is the number of schedules returned, 20 by default",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "preferences": {
+                    "$ref": "#/definitions/beepicker.SchedulePreferences"
+                },
+                "save": {
+                    "description": "Save stores the returned schedules as \"\u003cSave\u003e 1\", \"\u003cSave\u003e 2\", ... in the user's schedules,\nit fails if one of the names is taken",
+                    "type": "string"
+                },
+                "snapshot": {
+                    "type": "string"
+                },
+                "term": {
+                    "description": "Term or Snapshot plan with an older catalog, see /beePicker/terms",
+                    "type": "string"
+                }
+            }
+        },
+        "beepicker.SchedulePreferences": {
+            "type": "object",
+            "properties": {
+                "free_days": {
+                    "description": "FreeDays are the days to keep free, without them every free weekday counts",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "instructors": {
+                    "description": "Instructors are parts of the names of preferred instructors",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "not_before": {
+                    "description": "NotBefore (\"15:04\") is the preferred earliest start of a meeting",
+                    "type": "string"
+                }
+            }
+        },
+        "beepicker.conflictsRequest": {
+            "type": "object",
+            "required": [
+                "crns"
+            ],
+            "properties": {
+                "crns": {
+                    "type": "array",
+                    "maxItems": 30,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "beepicker.pickRequest": {
+            "type": "object",
+            "required": [
+                "courseCodes"
+            ],
+            "properties": {
+                "courseCodes": {
+                    "type": "array",
+                    "maxItems": 15,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "beepicker.renameScheduleRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "beepicker.scheduleCRNsRequest": {
+            "type": "object",
+            "properties": {
+                "ECRN": {
+                    "type": "array",
+                    "maxItems": 12,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "SCRN": {
+                    "type": "array",
+                    "maxItems": 12,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "beepicker.scheduleRequest": {
+            "type": "object",
+            "required": [
+                "name"
             ],
             "properties": {
-                "email": {
+                "ECRN": {
+                    "description": "ECRN are the CRNs to add, SCRN the CRNs to drop, at most 12 together",
+                    "type": "array",
+                    "maxItems": 12,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "SCRN": {
+                    "type": "array",
+                    "maxItems": 12,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "kepler.CRNResult": {
+            "type": "object",
+            "properties": {
+                "crn": {
+                    "type": "string"
+                },
+                "operationFin": {
+                    "type": "boolean"
+                },
+                "resultCode": {
+                    "type": "string"
+                },
+                "resultData": {
+                    "description": "ResultData is filled in by BeeHub with the explanation of ResultCode",
+                    "type": "string"
+                },
+                "statusCode": {
+                    "description": "StatusCode is 0 when the operation succeeded",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AcademicStatus": {
+            "type": "object",
+            "properties": {
+                "class": {
+                    "type": "string"
+                },
+                "gpa": {
+                    "type": "number"
+                },
+                "term_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CatalogDiff": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionRef"
+                    }
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionChange"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionRef"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CatalogSnapshot": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "Current is set for the snapshot /beePicker/courses serves by default",
+                    "type": "boolean"
+                },
+                "snapshot": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Conflict": {
+            "type": "object",
+            "properties": {
+                "first": {
+                    "$ref": "#/definitions/models.SectionMeeting"
+                },
+                "second": {
+                    "$ref": "#/definitions/models.SectionMeeting"
+                }
+            }
+        },
+        "models.ConflictReport": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Conflict"
+                    }
+                },
+                "unknown_crns": {
+                    "description": "UnknownCRNs are not in the course catalog and could not be checked",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Course": {
+            "type": "object",
+            "properties": {
+                "branch": {
                     "type": "string"
                 },
-                "password": {
+                "code": {
+                    "description": "Code is the branch code and number, e.g. \"BLG 101E\"",
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "number"
+                },
+                "language": {
+                    "description": "Language is \"en\" for courses taught in English (numbers ending in E), \"tr\" otherwise",
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Section"
+                    }
+                },
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "beepicker.pickRequest": {
+        "models.CourseMatch": {
             "type": "object",
             "properties": {
-                "courseCodes": {
+                "course": {
+                    "$ref": "#/definitions/models.Course"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CoursePage": {
+            "type": "object",
+            "properties": {
+                "courses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Course"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor fetches the next page, it is empty on the last page",
+                    "type": "string"
+                },
+                "schema_version": {
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Total is the number of courses matching the query over all pages",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GeneratedSchedule": {
+            "type": "object",
+            "properties": {
+                "crns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "early_meetings": {
+                    "description": "EarlyMeetings start before the preferred earliest time",
+                    "type": "integer"
+                },
+                "free_days": {
+                    "description": "FreeDays are the weekdays without a meeting",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "full_sections": {
+                    "description": "FullSections is the number of sections without seats left",
+                    "type": "integer"
+                },
+                "gap_hours": {
+                    "description": "GapHours are the idle hours between the meetings of a day, summed over the week",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name is set when the schedule was saved to the user's schedules",
+                    "type": "string"
+                },
+                "preferred_instructors": {
+                    "description": "PreferredInstructors is the number of sections taught by a preferred instructor",
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScheduledSection"
+                    }
+                }
+            }
+        },
+        "models.GeneratedSchedules": {
+            "type": "object",
+            "properties": {
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GeneratedSchedule"
+                    }
+                },
+                "total": {
+                    "description": "Total is the number of conflict-free schedules found",
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the search stopped early because there were too many schedules,\nTotal and the ranking then only cover the schedules found until then",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Meeting": {
+            "type": "object",
+            "properties": {
+                "building": {
+                    "type": "string"
+                },
+                "day": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "room": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Restrictions": {
+            "type": "object",
+            "properties": {
+                "class_level": {
+                    "type": "string"
+                },
+                "prerequisites": {
+                    "type": "string"
+                },
+                "programs": {
+                    "description": "Programs are the major codes allowed to register",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reservation": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScheduledSection": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "crn": {
+                    "type": "string"
+                },
+                "enrolled": {
+                    "type": "integer"
+                },
+                "instructors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "meetings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Meeting"
+                    }
+                },
+                "restrictions": {
+                    "$ref": "#/definitions/models.Restrictions"
+                },
+                "teaching_method": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Section": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "crn": {
+                    "type": "string"
+                },
+                "enrolled": {
+                    "type": "integer"
+                },
+                "instructors": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "meetings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Meeting"
+                    }
+                },
+                "restrictions": {
+                    "$ref": "#/definitions/models.Restrictions"
+                },
+                "teaching_method": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SectionChange": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "code": {
+                    "type": "string"
+                },
+                "crn": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SectionMeeting": {
+            "type": "object",
+            "properties": {
+                "building": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "crn": {
+                    "type": "string"
+                },
+                "day": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "room": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SectionRef": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "crn": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Term": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Transcript": {
+            "type": "object",
+            "properties": {
+                "cumulative_gpa": {
+                    "type": "number"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TranscriptTerm"
+                    }
+                },
+                "total_credits": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.TranscriptCourse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "number"
+                },
+                "ects": {
+                    "type": "number"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TranscriptTerm": {
+            "type": "object",
+            "properties": {
+                "courses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TranscriptCourse"
+                    }
+                },
+                "credits": {
+                    "type": "number"
+                },
+                "cumulative_gpa": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "term_gpa": {
+                    "type": "number"
                 }
             }
         },
-        "beepicker.scheduleSaveRequest": {
+        "utils.Schedule": {
             "type": "object",
             "properties": {
                 "ECRN": {
@@ -269,10 +1811,21 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
-                "scheduleName": {
+                "name": {
                     "type": "string"
                 }
             }
+        },
+        "utils.ScheduleList": {
+            "type": "object",
+            "properties": {
+                "schedules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.Schedule"
+                    }
+                }
+            }
         }
     }
 }`
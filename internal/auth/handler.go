@@ -1,10 +1,15 @@
 package auth
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/transcript"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,51 +26,93 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// AuthMiddleware checks if the user is authenticated
+// AuthMiddleware checks if the user is authenticated and stores their session in the request context.
+// The BeeHub token is read from an "Authorization: Bearer" header or from the token cookie,
+// its signature and expiry are validated before the session is looked up.
 func AuthMiddleware(authService *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		person := authService.personManager.GetPerson()
-		if person.Email == "" {
+		sess, err := authService.AuthenticateService(bearerToken(c))
+		if err != nil {
 			// User is not authenticated, redirect to login page
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
-			c.Abort()
+			apperr.Respond(c, err)
 			return
 		}
+		session.SetContext(c, sess)
 		c.Next()
 	}
 }
 
+func bearerToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	signed, err := c.Cookie(session.CookieName)
+	if err != nil {
+		return ""
+	}
+	return signed
+}
+
+// setTokenCookie stores the BeeHub token in a cookie that lives as long as the session.
+func setTokenCookie(c *gin.Context, sess *session.Session, signed string) {
+	maxAge := int(time.Until(sess.ExpiresAt).Seconds())
+	c.SetCookie(session.CookieName, signed, maxAge, "/", "", false, true)
+}
+
 // @Tags Login
 // @Summary Hello World
 // @Accept json
 // @Produce json
 // @Param login body LoginRequest true "Login credentials"
+// @Failure 401 {object} apperr.Envelope "Invalid credentials"
+// @Failure 429 {object} apperr.Envelope "Too many attempts, see the Retry-After header"
 // @Router /auth/login [post]
 func (h *Handler) LoginHandler(c *gin.Context) {
 	var req LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
 		return
 	}
 
-	token, err := h.authService.LoginService(req.Email, req.Password)
+	sess, err := h.authService.LoginService(req.Email, req.Password, c.ClientIP())
 	if err != nil {
-		switch err.Error() {
-		case "login failed":
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed"})
-
-		case "bad status":
-			c.JSON(http.StatusBadGateway, gin.H{"error": "kepler service unavailable"})
+		apperr.Respond(c, err)
+		return
+	}
 
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-		}
-	} else {
-		c.JSON(http.StatusOK, gin.H{"token": token})
+	signed, claims, err := h.authService.IssueTokenService(sess)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	setTokenCookie(c, sess, signed)
+	c.JSON(http.StatusOK, gin.H{"token": signed, "expires_at": claims.Expiry()})
+}
 
+// RefreshHandler rotates the BeeHub token of the caller. The old token stops working.
+// @Tags Login
+// @Summary Rotates the BeeHub token
+// @Produce json
+// @Router /auth/refresh [post]
+func (h *Handler) RefreshHandler(c *gin.Context) {
+	sess, signed, claims, err := h.authService.RefreshService(bearerToken(c))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
 	}
+	setTokenCookie(c, sess, signed)
+	c.JSON(http.StatusOK, gin.H{"token": signed, "expires_at": claims.Expiry()})
+}
 
+// @Tags Login
+// @Summary Ends the current session
+// @Produce json
+// @Router /auth/logout [post]
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	h.authService.LogoutService(session.FromContext(c))
+	c.SetCookie(session.CookieName, "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
 }
 
 // @Tags Profile
@@ -74,14 +121,130 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 // @Produce json
 // @Router /auth/profile [get]
 func (h *Handler) ProfileHandler(c *gin.Context) {
-	person := h.authService.personManager.GetPerson()
+	dto, err := h.authService.ProfileService(session.FromContext(c))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto)
+}
+
+// PhotoHandler serves the photo of the student as an image. Browsers may keep it for
+// as long as the session caches it and revalidate it with its ETag afterwards.
+// @Tags Profile
+// @Summary Returns the photo of the student
+// @Produce image/jpeg
+// @Produce image/png
+// @Success 200 {file} binary
+// @Success 304 "Not modified"
+// @Failure 404 {object} apperr.Envelope "Student has no photo"
+// @Router /auth/profile/photo [get]
+func (h *Handler) PhotoHandler(c *gin.Context) {
+	photo, err := h.authService.PhotoService(session.FromContext(c))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
 
-	if time.Since(person.LoginTime).Hours() >= 4 {
-		h.authService.LoginService(person.Email, person.Password)
+	// The photo is personal, shared caches must not keep it
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(h.authService.profileTTL.Seconds())))
+	c.Header("ETag", photo.ETag)
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, photo.ETag) {
+		c.Status(http.StatusNotModified)
+		return
 	}
-	dto, err := h.authService.ProfileService(person)
+	c.Data(http.StatusOK, photo.ContentType, photo.Data)
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// @Tags Profile
+// @Summary Lists the academic terms of the student
+// @Produce json
+// @Success 200 {array} models.Term
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Router /auth/terms [get]
+func (h *Handler) TermsHandler(c *gin.Context) {
+	terms, err := h.authService.TermsService(session.FromContext(c))
 	if err != nil {
-		log.Fatal(err)
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, terms)
+}
+
+// @Tags Profile
+// @Summary Returns the class level and GPA of the student in a term
+// @Produce json
+// @Param term query int false "Term ID from /auth/terms, the current term if omitted"
+// @Success 200 {object} models.AcademicStatus
+// @Failure 404 {object} apperr.Envelope "Unknown term"
+// @Router /auth/academic-status [get]
+func (h *Handler) AcademicStatusHandler(c *gin.Context) {
+	var termID int
+	if term := c.Query("term"); term != "" {
+		var err error
+		termID, err = strconv.Atoi(term)
+		if err != nil || termID <= 0 {
+			apperr.Respond(c, apperr.ErrBadRequest.WithMessage("term must be a term ID"))
+			return
+		}
+	}
+	status, err := h.authService.AcademicStatusService(session.FromContext(c), termID)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// TranscriptHandler returns the transcript of the student. By default it is parsed into terms,
+// courses and grades, with format=pdf (or an Accept header asking for a PDF) the document
+// Kepler produced is passed through as is.
+// @Tags Profile
+// @Summary Returns the transcript of the student
+// @Produce json
+// @Produce application/pdf
+// @Param format query string false "json (default) or pdf for the original document"
+// @Success 200 {object} models.Transcript
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 502 {object} apperr.Envelope "Transcript could not be fetched or read"
+// @Router /auth/transcript [get]
+func (h *Handler) TranscriptHandler(c *gin.Context) {
+	sess := session.FromContext(c)
+	format := c.Query("format")
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, transcript.ContentTypePDF) == transcript.ContentTypePDF {
+		format = "pdf"
+	}
+
+	switch format {
+	case "pdf":
+		data, contentType, err := h.authService.TranscriptService(sess)
+		if err != nil {
+			apperr.Respond(c, err)
+			return
+		}
+		if contentType == transcript.ContentTypePDF {
+			c.Header("Content-Disposition", `inline; filename="transcript.pdf"`)
+		}
+		c.Data(http.StatusOK, contentType, data)
+	case "", "json":
+		parsed, err := h.authService.ParsedTranscriptService(sess)
+		if err != nil {
+			apperr.Respond(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, parsed)
+	default:
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage("format must be json or pdf"))
 	}
-	c.JSON(http.StatusOK, dto)
 }
@@ -1,341 +1,436 @@
 package auth
 
 import (
-	"bytes"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"net/http/cookiejar"
-	"net/url"
 	"strings"
+	"time"
 
-	"github.com/ITU-BeeHub/BeeHub-backend/pkg"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
 	models "github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
-	"golang.org/x/net/html"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/ratelimit"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/token"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/transcript"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/vault"
 )
 
-const token_url = "https://obs.itu.edu.tr/ogrenci/auth/jwt"
-const photo_url = "https://obs.itu.edu.tr/api/ogrenci/OgrenciFotograf"
-const gpa_and_grade_url = "https://obs.itu.edu.tr/api/ogrenci/AkademikDurum/759"
-const personal_info_url = "https://obs.itu.edu.tr/api/ogrenci/KisiselBilgiler"
-const transcript_url = "https://obs.itu.edu.tr/api/ogrenci/Belgeler/TranskriptIngilizceOnizleme"
+// LoginLimiters throttle login attempts. Every attempt is forwarded to ITU SSO,
+// so guessing passwords through BeeHub could get the ITU account locked.
+type LoginLimiters struct {
+	IP       *ratelimit.Limiter
+	Username *ratelimit.Limiter
+}
 
 type Service struct {
-	personManager *pkg.PersonManager
+	kepler       kepler.KeplerClient
+	sessions     *session.Manager
+	signer       *token.Signer
+	vault        *vault.Vault
+	limiters     LoginLimiters
+	refreshAhead time.Duration
+	profileTTL   time.Duration
+	// fallbackTermID is the current term while the term list cannot be read from Kepler
+	fallbackTermID int
 }
 
-func NewService(personManager *pkg.PersonManager) *Service {
-	return &Service{personManager: personManager}
-}
+// PhotoURL serves the photo of the logged in student, it is linked from the profile.
+const PhotoURL = "/auth/profile/photo"
 
-func (s *Service) LoginService(email, password string) (string, error) {
+// Session cache keys
+const (
+	profileCacheKey = "auth.profile"
+	photoCacheKey   = "auth.photo"
+)
 
-	// Cookie jar oluştur
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		log.Fatal(err)
-	}
+// Photo is the decoded student photo.
+type Photo struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
 
-	// HTTP client oluştur ve cookie jar ekle
-	client := &http.Client{
-		Jar: jar,
+// NewService creates the auth service. Kepler tokens are renewed refreshAhead before they expire,
+// the credentials needed for that are kept in the vault for as long as the session lives.
+// Profiles and photos are cached in the session for profileTTL. fallbackTermID is used as
+// the current term when Kepler's term list cannot be read.
+func NewService(keplerClient kepler.KeplerClient, sessions *session.Manager, signer *token.Signer, credentials *vault.Vault, limiters LoginLimiters, refreshAhead, profileTTL time.Duration, fallbackTermID int) *Service {
+	sessions.OnRemove(func(sess *session.Session) {
+		if err := credentials.Delete(sess.ID); err != nil {
+			log.Println("Error deleting credentials of session:", err)
+		}
+	})
+	return &Service{
+		kepler:         keplerClient,
+		sessions:       sessions,
+		signer:         signer,
+		vault:          credentials,
+		limiters:       limiters,
+		refreshAhead:   refreshAhead,
+		profileTTL:     profileTTL,
+		fallbackTermID: fallbackTermID,
 	}
+}
 
-	// İlk GET isteği için headers tanımla
-	req, err := http.NewRequest("GET", "https://obs.itu.edu.tr", nil)
-	if err != nil {
-		log.Fatal(err)
+// LoginService logs the user in to Kepler and starts a new session for them.
+// The Kepler JWT is kept in the session and never leaves the server.
+// Attempts are rate limited per client IP and per username, wrong passwords lock both out for a while.
+func (s *Service) LoginService(email, password, clientIP string) (*session.Session, error) {
+	username := session.NormalizeUsername(email)
+	if wait, ok := s.limiters.IP.Allow(clientIP); !ok {
+		return nil, apperr.ErrTooManyRequests.WithRetryAfter(wait)
 	}
-	req.Header.Set("User-Agent", "BeeHub")
-	// İstek gönder
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatal(err)
+	if wait, ok := s.limiters.Username.Allow(username); !ok {
+		return nil, apperr.ErrTooManyRequests.WithRetryAfter(wait)
 	}
-	defer resp.Body.Close()
 
-	// Yönlendirme URL'sini bul
-	if len(resp.Request.Response.Request.URL.String()) == 0 {
-		fmt.Println("No redirect found")
+	keplerToken, err := s.kepler.Login(email, password)
+	if errors.Is(err, apperr.ErrInvalidCredentials) {
+		s.limiters.IP.Failure(clientIP)
+		if lockout := s.limiters.Username.Failure(username); lockout > 0 {
+			log.Printf("Too many failed logins for %s, locked out for %s", username, lockout)
+		}
 	}
-	loginURL := resp.Request.Response.Request.URL.String()
-
-	// İlk GET isteği için headers tanımla
-	req, err = http.NewRequest("GET", loginURL, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	s.limiters.Username.Reset(username)
 
-	// İstek gönder
-	resp, err = client.Do(req)
-	if err != nil {
-		log.Fatal(err)
+	person := &models.Person{
+		Email:     email,
+		Token:     keplerToken,
+		LoginTime: time.Now(),
 	}
-	defer resp.Body.Close()
-
-	// HTML'i ayrıştır
-	doc, err := html.Parse(resp.Body)
+	sess, err := s.sessions.Create(person)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	// HTML'den form verilerini çıkar
-	var viewstate, viewstategenerator, eventvalidation string
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "input" {
-			for _, attr := range n.Attr {
-				if attr.Key == "name" {
-					switch attr.Val {
-					case "__VIEWSTATE":
-						for _, a := range n.Attr {
-							if a.Key == "value" {
-								viewstate = a.Val
-							}
-						}
-					case "__VIEWSTATEGENERATOR":
-						for _, a := range n.Attr {
-							if a.Key == "value" {
-								viewstategenerator = a.Val
-							}
-						}
-					case "__EVENTVALIDATION":
-						for _, a := range n.Attr {
-							if a.Key == "value" {
-								eventvalidation = a.Val
-							}
-						}
-					}
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(doc)
-
-	// Form verilerini ve POST isteği için headers tanımla
-	formData := url.Values{
-		"__EVENTTARGET":                        {""},
-		"__EVENTARGUMENT":                      {""},
-		"__VIEWSTATE":                          {viewstate},
-		"__VIEWSTATEGENERATOR":                 {viewstategenerator},
-		"__EVENTVALIDATION":                    {eventvalidation},
-		"ctl00$ContentPlaceHolder1$hfAppName":  {"Öğrenci Bilgi Sistemi"},
-		"ctl00$ContentPlaceHolder1$tbUserName": {email},
-		"ctl00$ContentPlaceHolder1$tbPassword": {password},
-		"ctl00$ContentPlaceHolder1$btnLogin":   {"Giriş / Login"},
+		return nil, err
 	}
-
-	req, err = http.NewRequest("POST", loginURL, strings.NewReader(formData.Encode()))
+	err = s.vault.Put(sess.ID, vault.Credentials{Username: email, Password: password})
 	if err != nil {
-		log.Fatal(err)
+		s.sessions.Delete(sess.ID)
+		return nil, apperr.ErrInternal.Wrap(err)
 	}
+	s.startTokenKeeper(sess, keplerToken)
+	return sess, nil
+}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// POST isteğini gönder
-	resp, err = client.Do(req)
+// IssueTokenService issues a new BeeHub token for the session.
+// Only the latest token of a session is accepted, so this also revokes the previous one.
+func (s *Service) IssueTokenService(sess *session.Session) (string, token.Claims, error) {
+	signed, claims, err := s.signer.Issue(sess.Person.GetEmail(), sess.ID)
 	if err != nil {
-		log.Fatal(err)
+		return "", token.Claims{}, err
 	}
-	defer resp.Body.Close()
+	sess.SetTokenID(claims.ID)
+	return signed, claims, nil
+}
 
-	// Yanıtı kontrol et
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad status")
+// AuthenticateService validates a BeeHub token and returns the session it belongs to.
+func (s *Service) AuthenticateService(signed string) (*session.Session, error) {
+	claims, err := s.signer.Parse(signed)
+	if err != nil {
+		return nil, authError(err)
 	}
-
-	req, err = http.NewRequest("GET", token_url, nil)
+	sess, err := s.tokenSession(claims)
 	if err != nil {
-		log.Fatal(err)
+		return nil, authError(err)
 	}
+	return sess, nil
+}
 
-	resp, err = client.Do(req)
+// RefreshService rotates a BeeHub token. Expired tokens are accepted as long as
+// their session is still alive and they have not been rotated already.
+func (s *Service) RefreshService(signed string) (*session.Session, string, token.Claims, error) {
+	claims, err := s.signer.Parse(signed)
+	if err != nil && !errors.Is(err, token.ErrExpired) {
+		return nil, "", token.Claims{}, authError(err)
+	}
+	sess, err := s.tokenSession(claims)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", token.Claims{}, authError(err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	signed, claims, err = s.IssueTokenService(sess)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", token.Claims{}, err
 	}
+	return sess, signed, claims, nil
+}
 
-	// check if user is logged in
-	if !isLoggedIn(body) {
-		return "", fmt.Errorf("login failed")
+// authError maps token and session errors to the errors reported to the client.
+func authError(err error) error {
+	if errors.Is(err, token.ErrExpired) || errors.Is(err, session.ErrNotFound) {
+		return apperr.ErrSessionExpired.Wrap(err)
 	}
-	person := s.personManager.GetPerson()
-	s.personManager.SetEmail(email)
-	s.personManager.SetPassword(password)
-	s.personManager.UpdateLoginTime()
-	s.personManager.UpdateToken(string(body))
-	s.personManager.UpdatePerson(person)
-	return string(body), nil
-
+	return apperr.ErrUnauthenticated.Wrap(err)
 }
 
-// Returns true if user is logged in
-//
-// Works by checking if the response is a html document
-// If response is a html document then user is not logged in
-func isLoggedIn(body []byte) bool {
-
-	doc, err := html.Parse(bytes.NewReader(body))
+func (s *Service) tokenSession(claims token.Claims) (*session.Session, error) {
+	sess, err := s.sessions.Get(claims.SessionID)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	if sess.TokenID() != claims.ID {
+		return nil, token.ErrInvalid
+	}
+	return sess, nil
+}
 
-	// Check if the response contains a login form
-	var hasLoginForm bool
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "form" {
-			hasLoginForm = true
-			return
+// startTokenKeeper attaches a keeper to the session that logs in again with the
+// credentials stored in the vault before the Kepler JWT expires.
+func (s *Service) startTokenKeeper(sess *session.Session, keplerToken string) {
+	keeper := kepler.NewTokenKeeper(keplerToken, s.refreshAhead, func() (string, error) {
+		credentials, err := s.vault.Get(sess.ID)
+		if err != nil {
+			return "", apperr.ErrSessionExpired.Wrap(err)
 		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
+		keplerToken, err := s.kepler.Login(credentials.Username, credentials.Password)
+		if err != nil {
+			return "", err
 		}
-	}
-	f(doc)
+		sess.Person.UpdateToken(keplerToken)
+		sess.Person.UpdateLoginTime()
+		return keplerToken, nil
+	})
+	sess.SetTokenKeeper(keeper)
+	keeper.Start()
+}
 
-	return !hasLoginForm
+// LogoutService ends the session.
+func (s *Service) LogoutService(sess *session.Session) {
+	s.sessions.Delete(sess.ID)
 }
 
-func (s *Service) ProfileService(person *models.Person) (models.PersonDTO, error) {
-	token := "Bearer " + s.personManager.GetToken()
-	jar, err := cookiejar.New(nil)
+// ProfileService returns the profile of the student, served from the session cache while it is fresh.
+// The photo is not included, the profile links to PhotoURL instead.
+func (s *Service) ProfileService(sess *session.Session) (models.PersonDTO, error) {
+	if cached, ok := sess.Cached(profileCacheKey); ok {
+		return cached.(models.PersonDTO), nil
+	}
+
+	// Kişisel bilgiler
+	profile, err := keplerGet(sess.TokenKeeper(), s.kepler.Profile)
 	if err != nil {
-		log.Fatal(err)
+		return models.PersonDTO{}, err
 	}
-	// HTTP client oluştur ve cookie jar ekle
-	client := &http.Client{
-		Jar: jar,
+	// GPA ve sınıf
+	status, err := s.AcademicStatusService(sess, 0)
+	if err != nil {
+		return models.PersonDTO{}, err
+	}
+
+	// Person güncelle ve DTO oluştur. Token renewal and transcript uploads change
+	// the same person concurrently, so it is edited in place under its lock.
+	var personDTO models.PersonDTO
+	sess.Person.EditPerson(func(person *models.Person) {
+		enrichPerson(person, profile.PersonalInfo, status)
+		personDTO = models.ToPersonDTO(*person)
+	})
+	personDTO.Photo_url = PhotoURL
+	sess.Cache(profileCacheKey, personDTO, s.profileTTL)
+	return personDTO, nil
+}
+
+// enrichPerson fills person with the profile and academic status from Kepler.
+func enrichPerson(person *models.Person, info kepler.PersonalInfo, status models.AcademicStatus) {
+	person.Full_name = strings.Join(strings.Fields(info.FullName), " ")
+	person.First_name, person.Last_name = info.FirstName, info.LastName
+	if person.First_name == "" || person.Last_name == "" {
+		person.First_name, person.Last_name = splitName(person.Full_name)
+	}
+	if person.Full_name == "" {
+		person.Full_name = strings.TrimSpace(person.First_name + " " + person.Last_name)
+	}
+	if info.Email != "" {
+		person.Email = info.Email
+	}
+	person.Student_number = info.StudentNumber
+	person.Faculty = info.Faculty
+	person.Department = info.Department
+	person.Program = info.Program
+	person.Advisor = info.Advisor
+	person.Gender = info.Gender
+	person.Class = status.Class
+	if status.GPA != 0 {
+		person.GPA = fmt.Sprintf("%.2f", status.GPA)
 	}
+}
 
-	// İlk GET isteği için headers tanımla
-	req, err := http.NewRequest("GET", personal_info_url, nil)
+// PhotoService returns the decoded photo of the student, served from the session cache while it is fresh.
+func (s *Service) PhotoService(sess *session.Session) (Photo, error) {
+	if cached, ok := sess.Cached(photoCacheKey); ok {
+		return cached.(Photo), nil
+	}
+
+	response, err := keplerGet(sess.TokenKeeper(), s.kepler.Photo)
 	if err != nil {
-		log.Fatal(err)
+		return Photo{}, err
+	}
+	if response.Base64 == "" {
+		return Photo{}, apperr.ErrNotFound.WithMessage("student has no photo")
 	}
-	req.Header.Set("User-Agent", "BeeHub")
-	req.Header.Set("Authorization", token)
-	// İstek gönder
-	resp, err := client.Do(req)
+	data, err := base64.StdEncoding.DecodeString(response.Base64)
 	if err != nil {
-		log.Fatal(err)
+		return Photo{}, apperr.ErrUpstreamSchemaChanged.Wrap(err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatal(err)
+	sum := sha256.Sum256(data)
+	photo := Photo{
+		Data:        data,
+		ContentType: http.DetectContentType(data),
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
 	}
+	sess.Cache(photoCacheKey, photo, s.profileTTL)
+	return photo, nil
+}
 
-	var info_response map[string]interface{}
-	err = json.Unmarshal(body, &info_response)
-	if err != nil {
-		log.Fatal(err)
+// splitName splits a full name into given names and surname. Turkish students often
+// have several given names but a single surname, so only the last word is the surname.
+func splitName(fullName string) (string, string) {
+	names := strings.Fields(fullName)
+	if len(names) < 2 {
+		return fullName, ""
+	}
+	return strings.Join(names[:len(names)-1], " "), names[len(names)-1]
+}
 
+// classLevel reads the class number out of a level like "3. Sınıf", other levels
+// (e.g. "Hazırlık") are returned as they are.
+func classLevel(level string) string {
+	level = strings.TrimSpace(level)
+	if digits := strings.IndexFunc(level, func(r rune) bool { return r < '0' || r > '9' }); digits > 0 {
+		return level[:digits]
 	}
-	// Accessing the nested map and the adSoyad field
-	if kisiselBilgiler, ok := info_response["kisiselBilgiler"].(map[string]interface{}); ok {
+	return level
+}
 
-		name, ok := kisiselBilgiler["adSoyad"].(string)
-		if ok {
-			// Splitting the name into first and last name
-			names := strings.Split(name, " ")
-			if len(names) >= 2 {
+// TermsService returns the academic terms of the student. They are fetched from Kepler once per session.
+func (s *Service) TermsService(sess *session.Session) ([]models.Term, error) {
+	if terms := sess.Terms(); terms != nil {
+		return terms, nil
+	}
+	response, err := keplerGet(sess.TokenKeeper(), s.kepler.Terms)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Terms) == 0 {
+		return nil, apperr.ErrUpstreamSchemaChanged.Wrap(errors.New("term list is empty"))
+	}
 
-				person.First_name = names[0]
-				person.Last_name = names[1]
-			}
+	terms := make([]models.Term, 0, len(response.Terms))
+	latest, current := 0, false
+	for _, term := range response.Terms {
+		if term.ID == 0 {
+			continue
 		}
-		email, ok := kisiselBilgiler["ePosta"].(string)
-		if ok {
-			person.Email = email
+		terms = append(terms, models.Term{ID: term.ID, Code: term.Code, Name: term.Name, Current: term.Current})
+		current = current || term.Current
+		if term.ID > terms[latest].ID {
+			latest = len(terms) - 1
 		}
-		department, ok := kisiselBilgiler["bolumAdiEN"].(string)
-		if ok {
-			person.Department = department
-		}
-		faculty, ok := kisiselBilgiler["fakulteEN"].(string)
-		if ok {
-			person.Faculty = faculty
-		}
-	} else {
-		fmt.Println("kisiselBilgiler field is not a map or not found")
 	}
+	if len(terms) == 0 {
+		return nil, apperr.ErrUpstreamSchemaChanged.Wrap(errors.New("term list has no term IDs"))
+	}
+	// Without a flagged term the latest one is the current one
+	if !current {
+		terms[latest].Current = true
+	}
+	sess.SetTerms(terms)
+	return terms, nil
+}
 
-	// Fotoğraf isteği için headers tanımla
-	req, err = http.NewRequest("GET", photo_url, nil)
+// AcademicStatusService returns the class level and GPA of the student in the given term,
+// termID 0 selects the current term.
+func (s *Service) AcademicStatusService(sess *session.Session, termID int) (models.AcademicStatus, error) {
+	termID, err := s.academicTerm(sess, termID)
 	if err != nil {
-		log.Fatal(err)
+		return models.AcademicStatus{}, err
 	}
-	req.Header.Set("Authorization", token)
-	// İstek gönder
-	resp, err = client.Do(req)
+
+	response, err := keplerGet(sess.TokenKeeper(), func(token string) (*kepler.AcademicStatusResponse, error) {
+		return s.kepler.AcademicStatus(token, termID)
+	})
 	if err != nil {
-		log.Fatal(err)
+		return models.AcademicStatus{}, err
 	}
-	defer resp.Body.Close()
-	var photoResponse map[string]interface{}
-	body, err = io.ReadAll(resp.Body)
+	return models.AcademicStatus{
+		TermID: termID,
+		Class:  classLevel(response.AcademicStatus.ClassLevel),
+		GPA:    response.AcademicStatus.GPA,
+	}, nil
+}
+
+// academicTerm resolves termID 0 to the current term. While the term list cannot be
+// read the requested term is trusted and the current term is the configured fallback,
+// so the profile keeps working without DonemListesi.
+func (s *Service) academicTerm(sess *session.Session, termID int) (int, error) {
+	terms, err := s.TermsService(sess)
 	if err != nil {
-		log.Fatal(err)
+		if termID == 0 {
+			termID = s.fallbackTermID
+		}
+		log.Printf("Cannot read the term list, using term %d: %v", termID, err)
+		return termID, nil
 	}
-	err = json.Unmarshal(body, &photoResponse)
-	if err != nil {
-		log.Fatal(err)
+	term, ok := findTerm(terms, termID)
+	if !ok {
+		return 0, apperr.ErrNotFound.WithMessage("unknown term")
 	}
-	photoBase64, ok := photoResponse["base64Fotograf"].(string)
-	if ok {
-		person.Photo_base64 = photoBase64
+	return term.ID, nil
+}
+
+// findTerm returns the term with the given ID, or the current term for ID 0.
+func findTerm(terms []models.Term, id int) (models.Term, bool) {
+	for _, term := range terms {
+		if (id == 0 && term.Current) || (id != 0 && term.ID == id) {
+			return term, true
+		}
 	}
+	return models.Term{}, false
+}
 
-	// GPA ve sınıf isteği için headers tanımla
-	req, err = http.NewRequest("GET", gpa_and_grade_url, nil)
+// TranscriptService fetches the transcript document of the student, a PDF unless Kepler changes it.
+// The document is kept base64 encoded in the person for other features.
+func (s *Service) TranscriptService(sess *session.Session) ([]byte, string, error) {
+	body, err := keplerGet(sess.TokenKeeper(), s.kepler.Transcript)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", err
 	}
-	req.Header.Set("Authorization", token)
-	// İstek gönder
-	resp, err = client.Do(req)
+	data, contentType, err := transcript.Document(body)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", apperr.ErrUpstreamSchemaChanged.Wrap(err)
 	}
-	defer resp.Body.Close()
+	sess.Person.SetTranscript(base64.StdEncoding.EncodeToString(data))
+	return data, contentType, nil
+}
 
-	var classResponse map[string]interface{}
-	body, err = io.ReadAll(resp.Body)
+// ParsedTranscriptService returns the terms, courses and grades of the student's transcript.
+func (s *Service) ParsedTranscriptService(sess *session.Session) (*models.Transcript, error) {
+	data, contentType, err := s.TranscriptService(sess)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	err = json.Unmarshal(body, &classResponse)
+	parsed, err := transcript.Parse(transcript.Text(data, contentType))
 	if err != nil {
-		log.Fatal(err)
-
-	}
-
-	if academicInfo, ok := classResponse["akademikDurum"].(map[string]interface{}); ok {
-		class, ok := academicInfo["sinifSeviye"].(string)
-		if ok {
-			person.Class = string(class)[0:1]
-		}
-		gpa, ok := academicInfo["genelNotOrtalamasi"].(float64)
-		if ok {
-			person.GPA = fmt.Sprintf("%.2f", gpa)
-		}
+		return nil, apperr.ErrUpstreamSchemaChanged.WithMessage("transcript could not be read").Wrap(err)
 	}
+	return parsed, nil
+}
 
-	// Person güncelle ve DTO oluştur
-	s.personManager.UpdatePerson(person)
-	personDTO := models.ToPersonDTO(*person)
-	return personDTO, nil
+// keplerGet calls a Kepler endpoint with the session's token.
+// A 401 response makes the keeper renew the token and retry once.
+func keplerGet[T any](keeper *kepler.TokenKeeper, get func(token string) (T, error)) (T, error) {
+	var response T
+	err := keeper.Do(func(token string) error {
+		var err error
+		response, err = get(token)
+		return err
+	})
+	return response, err
 }
@@ -0,0 +1,373 @@
+package beepicker
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// CourseQuery filters, sorts and pages the course catalog. Every filter is optional;
+// sections that do not match are left out and courses without a matching section are dropped.
+type CourseQuery struct {
+	Branch     string `form:"branch"`
+	Number     string `form:"number"`
+	CRN        string `form:"crn"`
+	Instructor string `form:"instructor"`
+	// Day is an English or Turkish day name
+	Day string `form:"day"`
+	// From and To ("15:04") keep sections whose meetings all lie in the window
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Building string `form:"building"`
+	// Available keeps sections with at least one seat left, MinSeats with at least that many
+	Available bool   `form:"available"`
+	MinSeats  int    `form:"min_seats" binding:"min=0"`
+	Language  string `form:"language" binding:"omitempty,oneof=en tr"`
+	// Sort is code, title, credits or seats, prefixed with - for descending order
+	Sort string `form:"sort" binding:"omitempty,oneof=code -code title -title credits -credits seats -seats"`
+	// Limit of 0 returns every matching course
+	Limit  int    `form:"limit" binding:"min=0,max=1000"`
+	Cursor string `form:"cursor"`
+
+	// Term or Snapshot select an older catalog instead of the current one, see /beePicker/terms
+	Term     string `form:"term"`
+	Snapshot string `form:"snapshot"`
+}
+
+var errBadCursor = apperr.ErrBadRequest.WithMessage("invalid or expired cursor")
+
+// catalogIndex is built once per catalog refresh so that queries do not scan every section.
+type catalogIndex struct {
+	catalog   *models.CourseCatalog
+	fetchedAt time.Time
+	// source and version name the offering the catalog was built from
+	source, version string
+	// Course positions by lowercase branch code, CRN, building and English day
+	byBranch   map[string][]int
+	byCRN      map[string]int
+	byBuilding map[string][]int
+	byDay      map[string][]int
+	// orders holds the course positions in ascending order for every sort key,
+	// ranks the position of every course code in that order
+	orders map[string][]int
+	ranks  map[string]map[string]int
+	// searchEntries holds the normalized words of every course for search
+	searchEntries []searchEntry
+}
+
+var sortKeys = map[string]func(a, b *models.Course) int{
+	"code": func(a, b *models.Course) int { return 0 },
+	"title": func(a, b *models.Course) int {
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	},
+	"credits": func(a, b *models.Course) int { return compare(a.Credits, b.Credits) },
+	"seats":   func(a, b *models.Course) int { return compare(seatsLeft(a), seatsLeft(b)) },
+}
+
+func newCatalogIndex(catalog *models.CourseCatalog) *catalogIndex {
+	index := &catalogIndex{
+		catalog:    catalog,
+		byBranch:   make(map[string][]int),
+		byCRN:      make(map[string]int),
+		byBuilding: make(map[string][]int),
+		byDay:      make(map[string][]int),
+		orders:     make(map[string][]int),
+		ranks:      make(map[string]map[string]int),
+	}
+	for i, course := range catalog.Courses {
+		index.searchEntries = append(index.searchEntries, newSearchEntry(&course))
+		index.byBranch[strings.ToLower(course.Branch)] = append(index.byBranch[strings.ToLower(course.Branch)], i)
+		buildings, days := make(map[string]bool), make(map[string]bool)
+		for _, section := range course.Sections {
+			index.byCRN[section.CRN] = i
+			for _, meeting := range section.Meetings {
+				buildings[strings.ToLower(meeting.Building)] = true
+				days[strings.ToLower(meeting.Day)] = true
+			}
+		}
+		for building := range buildings {
+			index.byBuilding[building] = append(index.byBuilding[building], i)
+		}
+		for day := range days {
+			index.byDay[day] = append(index.byDay[day], i)
+		}
+	}
+
+	for key, cmp := range sortKeys {
+		order := make([]int, len(catalog.Courses))
+		for i := range order {
+			order[i] = i
+		}
+		// Ties are broken by code, so every order is total and cursors stay stable
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := &catalog.Courses[order[i]], &catalog.Courses[order[j]]
+			if c := cmp(a, b); c != 0 {
+				return c < 0
+			}
+			return a.Code < b.Code
+		})
+		ranks := make(map[string]int, len(order))
+		for rank, i := range order {
+			ranks[catalog.Courses[i].Code] = rank
+		}
+		index.orders[key] = order
+		index.ranks[key] = ranks
+	}
+	return index
+}
+
+// query runs q against the index.
+func (index *catalogIndex) query(q CourseQuery) (*models.CoursePage, error) {
+	match, err := newSectionMatcher(q)
+	if err != nil {
+		return nil, err
+	}
+
+	key, descending := strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+	if key == "" {
+		key = "code"
+	}
+	order, ranks := index.orders[key], index.ranks[key]
+
+	// Resume after the course the cursor points at
+	start := 0
+	if q.Cursor != "" {
+		cursorKey, code, err := decodeCursor(q.Cursor)
+		if err != nil || cursorKey != q.Sort {
+			return nil, errBadCursor
+		}
+		rank, ok := ranks[code]
+		if !ok {
+			return nil, errBadCursor
+		}
+		start = rank + 1
+		if descending {
+			start = len(order) - rank
+		}
+	}
+
+	candidates := index.candidates(q)
+	page := &models.CoursePage{SchemaVersion: index.catalog.SchemaVersion, Courses: []models.Course{}}
+	for n := 0; n < len(order); n++ {
+		position := n
+		if descending {
+			position = len(order) - 1 - n
+		}
+		i := order[position]
+		if candidates != nil && !candidates[i] {
+			continue
+		}
+		course, ok := match.course(&index.catalog.Courses[i])
+		if !ok {
+			continue
+		}
+		page.Total++
+		if n < start {
+			continue
+		}
+		if q.Limit > 0 && len(page.Courses) == q.Limit {
+			if page.NextCursor == "" {
+				page.NextCursor = encodeCursor(q.Sort, page.Courses[len(page.Courses)-1].Code)
+			}
+			continue
+		}
+		page.Courses = append(page.Courses, course)
+	}
+	return page, nil
+}
+
+// candidates narrows the courses down with the exact match indexes, nil means every course.
+func (index *catalogIndex) candidates(q CourseQuery) map[int]bool {
+	var lists [][]int
+	if q.Branch != "" {
+		lists = append(lists, index.byBranch[strings.ToLower(q.Branch)])
+	}
+	if q.CRN != "" {
+		if i, ok := index.byCRN[q.CRN]; ok {
+			lists = append(lists, []int{i})
+		} else {
+			lists = append(lists, nil)
+		}
+	}
+	if q.Building != "" {
+		lists = append(lists, index.byBuilding[strings.ToLower(q.Building)])
+	}
+	if q.Day != "" {
+		lists = append(lists, index.byDay[strings.ToLower(englishDay(q.Day))])
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+
+	candidates := make(map[int]bool)
+	for _, i := range lists[0] {
+		candidates[i] = true
+	}
+	for _, list := range lists[1:] {
+		next := make(map[int]bool)
+		for _, i := range list {
+			if candidates[i] {
+				next[i] = true
+			}
+		}
+		candidates = next
+	}
+	return candidates
+}
+
+// sectionMatcher applies the section level filters of a query.
+type sectionMatcher struct {
+	q          CourseQuery
+	day        string
+	instructor string
+	// from and to are minutes past midnight, -1 when not set
+	from, to int
+	minSeats int
+}
+
+func newSectionMatcher(q CourseQuery) (*sectionMatcher, error) {
+	m := &sectionMatcher{
+		q:          q,
+		day:        englishDay(q.Day),
+		instructor: strings.Join(strings.Fields(normalize(q.Instructor)), " "),
+		from:       -1,
+		to:         -1,
+		minSeats:   q.MinSeats,
+	}
+	if q.Available && m.minSeats < 1 {
+		m.minSeats = 1
+	}
+	for _, window := range []struct {
+		value string
+		dst   *int
+	}{{q.From, &m.from}, {q.To, &m.to}} {
+		if window.value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", window.value); err != nil {
+			return nil, apperr.ErrBadRequest.WithMessage("from and to must look like 15:04")
+		}
+		*window.dst = clockMinutes(window.value)
+	}
+	return m, nil
+}
+
+// course returns a copy of course holding only the matching sections.
+func (m *sectionMatcher) course(course *models.Course) (models.Course, bool) {
+	if m.q.Branch != "" && !strings.EqualFold(course.Branch, m.q.Branch) {
+		return models.Course{}, false
+	}
+	if m.q.Number != "" && !strings.EqualFold(course.Number, m.q.Number) {
+		return models.Course{}, false
+	}
+	if m.q.Language != "" && course.Language != m.q.Language {
+		return models.Course{}, false
+	}
+
+	matched := *course
+	matched.Sections = []models.Section{}
+	for _, section := range course.Sections {
+		if m.section(&section) {
+			matched.Sections = append(matched.Sections, section)
+		}
+	}
+	return matched, len(matched.Sections) > 0
+}
+
+func (m *sectionMatcher) section(section *models.Section) bool {
+	if m.q.CRN != "" && section.CRN != m.q.CRN {
+		return false
+	}
+	if m.minSeats > 0 && section.Capacity-section.Enrolled < m.minSeats {
+		return false
+	}
+	if m.instructor != "" && !containsNormalized(section.Instructors, m.instructor) {
+		return false
+	}
+	if m.day != "" && !hasMeeting(section, func(meeting models.Meeting) bool { return strings.EqualFold(meeting.Day, m.day) }) {
+		return false
+	}
+	if m.q.Building != "" && !hasMeeting(section, func(meeting models.Meeting) bool { return strings.EqualFold(meeting.Building, m.q.Building) }) {
+		return false
+	}
+	// Meetings without a time fit every time window
+	for _, meeting := range section.Meetings {
+		if meeting.Start == "" || meeting.End == "" {
+			continue
+		}
+		if m.from >= 0 && clockMinutes(meeting.Start) < m.from || m.to >= 0 && clockMinutes(meeting.End) > m.to {
+			return false
+		}
+	}
+	return true
+}
+
+func hasMeeting(section *models.Section, fn func(models.Meeting) bool) bool {
+	for _, meeting := range section.Meetings {
+		if fn(meeting) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNormalized reports whether a value contains the normalized substr, ignoring Turkish letters.
+func containsNormalized(values []string, substr string) bool {
+	for _, value := range values {
+		if strings.Contains(strings.Join(strings.Fields(normalize(value)), " "), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// englishDay maps a Turkish day name to English, English names are returned as is.
+func englishDay(day string) string {
+	day = strings.TrimSpace(day)
+	for turkish, english := range turkishDays {
+		if normalize(turkish) == normalize(day) {
+			return english
+		}
+	}
+	return day
+}
+
+// seatsLeft is the number of free seats over every section of a course.
+func seatsLeft(course *models.Course) int {
+	seats := 0
+	for _, section := range course.Sections {
+		seats += max(section.Capacity-section.Enrolled, 0)
+	}
+	return seats
+}
+
+func compare[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Cursors are opaque to clients: the sort they were issued for and the last course code returned.
+func encodeCursor(sort, code string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sort + "\x00" + code))
+}
+
+func decodeCursor(cursor string) (string, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", err
+	}
+	sort, code, ok := strings.Cut(string(data), "\x00")
+	if !ok {
+		return "", "", errBadCursor
+	}
+	return sort, code, nil
+}
@@ -0,0 +1,173 @@
+package beepicker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+func testCatalog() *catalogIndex {
+	return newCatalogIndex(&models.CourseCatalog{Courses: []models.Course{
+		{Code: "BLG 101E", Branch: "BLG", Number: "101E", Title: "Introduction to Programming", Language: "en", Credits: 3, Sections: []models.Section{
+			{CRN: "11", Instructors: []string{"Ayşe Yılmaz"}, Capacity: 50, Enrolled: 50, Meetings: []models.Meeting{{Day: "Monday", Start: "8:30", End: "10:29", Building: "EEB"}}},
+			{CRN: "12", Instructors: []string{"Mehmet Öz"}, Capacity: 50, Enrolled: 40, Meetings: []models.Meeting{{Day: "Tuesday", Start: "13:30", End: "15:29", Building: "MED"}}},
+		}},
+		{Code: "BLG 102E", Branch: "BLG", Number: "102E", Title: "Data Structures", Language: "en", Credits: 4, Sections: []models.Section{
+			{CRN: "21", Instructors: []string{"Ayşe Yılmaz"}, Capacity: 30, Enrolled: 10, Meetings: []models.Meeting{{Day: "Monday", Start: "10:30", End: "12:29", Building: "EEB"}}},
+		}},
+		{Code: "FIZ 101", Branch: "FIZ", Number: "101", Title: "Fizik", Language: "tr", Credits: 4, Sections: []models.Section{
+			{CRN: "31", Instructors: []string{"Işık Çelik"}, Capacity: 100, Enrolled: 99, Meetings: []models.Meeting{{Day: "Wednesday", Start: "9:00", End: "9:59", Building: "FEB"}, {Day: "Friday"}}},
+		}},
+		{Code: "MAT 103", Branch: "MAT", Number: "103", Title: "Matematik", Language: "tr", Credits: 4, Sections: []models.Section{
+			{CRN: "41", Instructors: []string{"Ali Kaya"}, Capacity: 80, Enrolled: 0},
+		}},
+		{Code: "MAT 104", Branch: "MAT", Number: "104", Title: "Calculus", Language: "tr", Credits: 2, Sections: []models.Section{
+			{CRN: "51", Instructors: []string{"Ali Kaya"}, Capacity: 20, Enrolled: 5, Meetings: []models.Meeting{{Day: "Thursday", Start: "15:30", End: "17:29", Building: "FEB"}}},
+		}},
+	}})
+}
+
+// crns lists the matched courses as "code:crn,crn".
+func crns(page *models.CoursePage) []string {
+	courses := []string{}
+	for _, course := range page.Courses {
+		sections := []string{}
+		for _, section := range course.Sections {
+			sections = append(sections, section.CRN)
+		}
+		courses = append(courses, course.Code+":"+strings.Join(sections, ","))
+	}
+	return courses
+}
+
+func TestQueryFilters(t *testing.T) {
+	index := testCatalog()
+	tests := []struct {
+		name string
+		q    CourseQuery
+		want []string
+	}{
+		{"everything", CourseQuery{}, []string{"BLG 101E:11,12", "BLG 102E:21", "FIZ 101:31", "MAT 103:41", "MAT 104:51"}},
+		{"branch", CourseQuery{Branch: "blg"}, []string{"BLG 101E:11,12", "BLG 102E:21"}},
+		{"number", CourseQuery{Branch: "BLG", Number: "102e"}, []string{"BLG 102E:21"}},
+		{"crn", CourseQuery{CRN: "12"}, []string{"BLG 101E:12"}},
+		{"unknown crn", CourseQuery{CRN: "99"}, []string{}},
+		{"instructor without Turkish letters", CourseQuery{Instructor: "ayse  yilmaz"}, []string{"BLG 101E:11", "BLG 102E:21"}},
+		{"instructor with Turkish letters", CourseQuery{Instructor: "IŞIK"}, []string{"FIZ 101:31"}},
+		{"turkish day", CourseQuery{Day: "Pazartesi"}, []string{"BLG 101E:11", "BLG 102E:21"}},
+		{"building", CourseQuery{Building: "feb"}, []string{"FIZ 101:31", "MAT 104:51"}},
+		{"building and day", CourseQuery{Building: "EEB", Day: "Tuesday"}, []string{}},
+		{"available", CourseQuery{Available: true}, []string{"BLG 101E:12", "BLG 102E:21", "FIZ 101:31", "MAT 103:41", "MAT 104:51"}},
+		{"min seats", CourseQuery{MinSeats: 15}, []string{"BLG 102E:21", "MAT 103:41", "MAT 104:51"}},
+		{"language", CourseQuery{Language: "tr", Branch: "MAT"}, []string{"MAT 103:41", "MAT 104:51"}},
+		// "8:30" and "9:59" sort after "10:00" and "12:00" as strings
+		{"from with a single digit hour", CourseQuery{From: "10:00"}, []string{"BLG 101E:12", "BLG 102E:21", "MAT 103:41", "MAT 104:51"}},
+		{"to with a single digit hour", CourseQuery{To: "12:00"}, []string{"BLG 101E:11", "FIZ 101:31", "MAT 103:41"}},
+		{"window", CourseQuery{From: "08:00", To: "12:30"}, []string{"BLG 101E:11", "BLG 102E:21", "FIZ 101:31", "MAT 103:41"}},
+		{"unpadded window", CourseQuery{From: "9:00", To: "16:00"}, []string{"BLG 101E:12", "BLG 102E:21", "FIZ 101:31", "MAT 103:41"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := index.query(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := crns(page); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query() = %q, want %q", got, tt.want)
+			}
+			if page.Total != len(tt.want) || page.NextCursor != "" {
+				t.Errorf("Total = %d, NextCursor = %q, want %d and none", page.Total, page.NextCursor, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestQueryBadWindow(t *testing.T) {
+	for _, q := range []CourseQuery{{From: "8"}, {To: "25:00"}, {From: "noon"}} {
+		if _, err := testCatalog().query(q); !errors.Is(err, apperr.ErrBadRequest) {
+			t.Errorf("query(%+v) error = %v, want ErrBadRequest", q, err)
+		}
+	}
+}
+
+// Paging through a query with a cursor returns the same courses as one unlimited query.
+func TestQueryCursor(t *testing.T) {
+	index := testCatalog()
+	tests := []struct {
+		name string
+		q    CourseQuery
+		want []string
+	}{
+		{"code", CourseQuery{}, []string{"BLG 101E", "BLG 102E", "FIZ 101", "MAT 103", "MAT 104"}},
+		{"descending code", CourseQuery{Sort: "-code"}, []string{"MAT 104", "MAT 103", "FIZ 101", "BLG 102E", "BLG 101E"}},
+		// Ties are broken by code in both directions
+		{"credits", CourseQuery{Sort: "credits"}, []string{"MAT 104", "BLG 101E", "BLG 102E", "FIZ 101", "MAT 103"}},
+		{"descending credits", CourseQuery{Sort: "-credits"}, []string{"MAT 103", "FIZ 101", "BLG 102E", "BLG 101E", "MAT 104"}},
+		{"seats", CourseQuery{Sort: "-seats"}, []string{"MAT 103", "BLG 102E", "MAT 104", "BLG 101E", "FIZ 101"}},
+		{"title", CourseQuery{Sort: "title"}, []string{"MAT 104", "BLG 102E", "FIZ 101", "BLG 101E", "MAT 103"}},
+		{"filtered", CourseQuery{Sort: "-code", Branch: "BLG"}, []string{"BLG 102E", "BLG 101E"}},
+		{"filtered by window", CourseQuery{From: "10:00"}, []string{"BLG 101E", "BLG 102E", "MAT 103", "MAT 104"}},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 10} {
+			t.Run(fmt.Sprintf("%s/limit %d", tt.name, limit), func(t *testing.T) {
+				q := tt.q
+				q.Limit = limit
+				got := []string{}
+				for pages := 0; ; pages++ {
+					if pages > len(tt.want) {
+						t.Fatal("the cursor does not advance")
+					}
+					page, err := index.query(q)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if page.Total != len(tt.want) {
+						t.Errorf("Total = %d, want %d", page.Total, len(tt.want))
+					}
+					if len(page.Courses) > limit {
+						t.Fatalf("page has %d courses, limit %d", len(page.Courses), limit)
+					}
+					for _, course := range page.Courses {
+						got = append(got, course.Code)
+					}
+					if page.NextCursor == "" {
+						break
+					}
+					q.Cursor = page.NextCursor
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("pages = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestQueryBadCursor(t *testing.T) {
+	index := testCatalog()
+	tests := []struct {
+		name   string
+		q      CourseQuery
+		cursor string
+	}{
+		{"garbage", CourseQuery{}, "not a cursor!"},
+		{"no separator", CourseQuery{}, "Y29kZQ"},
+		{"other sort", CourseQuery{Sort: "credits"}, encodeCursor("-credits", "BLG 101E")},
+		{"unknown course", CourseQuery{}, encodeCursor("", "BLG 999")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.q
+			q.Cursor = tt.cursor
+			if _, err := index.query(q); !errors.Is(err, errBadCursor) {
+				t.Errorf("query() error = %v, want errBadCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package beepicker
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// ChangesService diffs the catalogs of two snapshots, an empty to is the current catalog.
+func (s *Service) ChangesService(from, to string) (*models.CatalogDiff, error) {
+	fromIndex, err := s.selectIndex("", from)
+	if err != nil {
+		return nil, err
+	}
+	toIndex, err := s.selectIndex("", to)
+	if err != nil {
+		return nil, err
+	}
+	return diffCatalogs(fromIndex, toIndex), nil
+}
+
+// sectionOf is a section together with its course.
+type sectionOf struct {
+	course  *models.Course
+	section *models.Section
+}
+
+func sectionsByCRN(index *catalogIndex) map[string]sectionOf {
+	sections := make(map[string]sectionOf)
+	for i := range index.catalog.Courses {
+		course := &index.catalog.Courses[i]
+		for j := range course.Sections {
+			sections[course.Sections[j].CRN] = sectionOf{course: course, section: &course.Sections[j]}
+		}
+	}
+	return sections
+}
+
+// diffCatalogs lists the sections added to and removed from a catalog and the
+// instructor, time, room and capacity changes of the sections in both.
+func diffCatalogs(from, to *catalogIndex) *models.CatalogDiff {
+	diff := &models.CatalogDiff{
+		From:    from.version,
+		To:      to.version,
+		Added:   []models.SectionRef{},
+		Removed: []models.SectionRef{},
+		Changed: []models.SectionChange{},
+	}
+	before, after := sectionsByCRN(from), sectionsByCRN(to)
+
+	for crn, old := range before {
+		current, ok := after[crn]
+		if !ok {
+			diff.Removed = append(diff.Removed, sectionRef(old))
+			continue
+		}
+		if changes := sectionChanges(old.section, current.section); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, models.SectionChange{
+				SectionRef: sectionRef(current),
+				Changes:    changes,
+			})
+		}
+	}
+	for crn, current := range after {
+		if _, ok := before[crn]; !ok {
+			diff.Added = append(diff.Added, sectionRef(current))
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].CRN < diff.Added[j].CRN })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].CRN < diff.Removed[j].CRN })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].CRN < diff.Changed[j].CRN })
+	return diff
+}
+
+func sectionRef(s sectionOf) models.SectionRef {
+	return models.SectionRef{CRN: s.section.CRN, Code: s.course.Code, Title: s.course.Title}
+}
+
+func sectionChanges(old, current *models.Section) []models.FieldChange {
+	var changes []models.FieldChange
+	check := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, models.FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	check("instructors", strings.Join(old.Instructors, ", "), strings.Join(current.Instructors, ", "))
+	check("times", meetingTimes(old), meetingTimes(current))
+	check("rooms", meetingRooms(old), meetingRooms(current))
+	check("capacity", strconv.Itoa(old.Capacity), strconv.Itoa(current.Capacity))
+	return changes
+}
+
+// meetingTimes reads like "Monday 08:30-10:29; Tuesday 13:30-15:29".
+func meetingTimes(section *models.Section) string {
+	times := make([]string, len(section.Meetings))
+	for i, meeting := range section.Meetings {
+		times[i] = fmt.Sprintf("%s %s-%s", meeting.Day, meeting.Start, meeting.End)
+	}
+	return strings.Join(times, "; ")
+}
+
+// meetingRooms reads like "EEB 5202; MED A11".
+func meetingRooms(section *models.Section) string {
+	rooms := make([]string, len(section.Meetings))
+	for i, meeting := range section.Meetings {
+		rooms[i] = strings.TrimSpace(meeting.Building + " " + meeting.Room)
+	}
+	return strings.Join(rooms, "; ")
+}
+
+// changeFeed hands the catalog changes picked up by refreshes to the subscribers,
+// e.g. clients following /beePicker/changes/events.
+type changeFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan *models.CatalogDiff]bool
+}
+
+var catalogChanges = &changeFeed{subscribers: make(map[chan *models.CatalogDiff]bool)}
+
+// Subscribe returns a channel receiving every catalog change until Unsubscribe is called.
+func (f *changeFeed) Subscribe() chan *models.CatalogDiff {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan *models.CatalogDiff, 4)
+	f.subscribers[ch] = true
+	return ch
+}
+
+func (f *changeFeed) Unsubscribe(ch chan *models.CatalogDiff) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscribers, ch)
+}
+
+// publish logs a change and sends it to the subscribers. Subscribers that do not
+// keep up miss changes instead of holding the refresh up.
+func (f *changeFeed) publish(diff *models.CatalogDiff) {
+	log.Printf("Course catalog changed from %s to %s: %d sections added, %d removed, %d changed",
+		diff.From, diff.To, len(diff.Added), len(diff.Removed), len(diff.Changed))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subscribers {
+		select {
+		case ch <- diff:
+		default:
+		}
+	}
+}
+
+// publishChanges diffs a refreshed catalog against the one it replaces.
+func publishChanges(previous, current *catalogIndex) {
+	if previous == nil {
+		return
+	}
+	diff := diffCatalogs(previous, current)
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		catalogChanges.publish(diff)
+	}
+}
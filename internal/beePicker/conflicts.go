@@ -0,0 +1,104 @@
+package beepicker
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// ConflictService reports the overlapping meetings of a set of CRNs in the current catalog.
+func (s *Service) ConflictService(crns []string) (*models.ConflictReport, error) {
+	index, err := s.courseIndex()
+	if err != nil {
+		return nil, err
+	}
+	return findConflicts(index, crns), nil
+}
+
+// checkConflicts rejects CRNs that overlap before they cost a Kepler registration call,
+// which would fail them with VAL09. Registration is time critical, so only a catalog
+// already in memory is used; without one the check is skipped and Kepler decides.
+func (s *Service) checkConflicts(crns []string) error {
+	index := cache.Load()
+	if index == nil {
+		log.Println("Skipping the conflict check, the course catalog is not loaded yet")
+		s.startRefresh()
+		return nil
+	}
+	report := findConflicts(index, crns)
+	if len(report.Conflicts) == 0 {
+		return nil
+	}
+
+	first := report.Conflicts[0]
+	message := fmt.Sprintf("CRN %s and CRN %s overlap on %s", first.First.CRN, first.Second.CRN, first.First.Day)
+	if len(report.Conflicts) > 1 {
+		message += fmt.Sprintf(" (%d conflicts, see /beePicker/conflicts)", len(report.Conflicts))
+	}
+	return apperr.ErrConflict.WithMessage(message)
+}
+
+// findConflicts compares every meeting of every section with the meetings of the
+// sections after it, so sections meeting more than once a week are fully checked.
+func findConflicts(index *catalogIndex, crns []string) *models.ConflictReport {
+	report := &models.ConflictReport{Conflicts: []models.Conflict{}, UnknownCRNs: []string{}}
+
+	var meetings [][]models.SectionMeeting
+	seen := make(map[string]bool)
+	for _, crn := range crns {
+		if seen[crn] {
+			continue
+		}
+		seen[crn] = true
+
+		section, course, ok := index.section(crn)
+		if !ok {
+			report.UnknownCRNs = append(report.UnknownCRNs, crn)
+			continue
+		}
+		var sectionMeetings []models.SectionMeeting
+		for _, meeting := range section.Meetings {
+			if meeting.Start != "" && meeting.End != "" {
+				sectionMeetings = append(sectionMeetings, models.SectionMeeting{CRN: crn, Code: course.Code, Meeting: meeting})
+			}
+		}
+		meetings = append(meetings, sectionMeetings)
+	}
+
+	for i := range meetings {
+		for j := i + 1; j < len(meetings); j++ {
+			for _, a := range meetings[i] {
+				for _, b := range meetings[j] {
+					if overlap(a.Meeting, b.Meeting) {
+						report.Conflicts = append(report.Conflicts, models.Conflict{First: a, Second: b})
+					}
+				}
+			}
+		}
+	}
+	return report
+}
+
+// overlap reports whether two meetings share a moment; meetings back to back do not overlap.
+// The times are compared in minutes, the catalog does not zero-pad hours ("8:30").
+func overlap(a, b models.Meeting) bool {
+	return a.Day == b.Day &&
+		clockMinutes(a.Start) < clockMinutes(b.End) && clockMinutes(b.Start) < clockMinutes(a.End)
+}
+
+// section finds a section and its course by CRN.
+func (index *catalogIndex) section(crn string) (*models.Section, *models.Course, bool) {
+	i, ok := index.byCRN[crn]
+	if !ok {
+		return nil, nil, false
+	}
+	course := &index.catalog.Courses[i]
+	for j := range course.Sections {
+		if course.Sections[j].CRN == crn {
+			return &course.Sections[j], course, true
+		}
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,82 @@
+package beepicker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b models.Meeting
+		want bool
+	}{
+		{"same time", models.Meeting{Day: "Monday", Start: "08:30", End: "10:29"}, models.Meeting{Day: "Monday", Start: "08:30", End: "10:29"}, true},
+		{"partly", models.Meeting{Day: "Monday", Start: "09:30", End: "11:29"}, models.Meeting{Day: "Monday", Start: "10:30", End: "12:29"}, true},
+		{"inside", models.Meeting{Day: "Monday", Start: "08:30", End: "12:29"}, models.Meeting{Day: "Monday", Start: "09:30", End: "10:29"}, true},
+		{"back to back", models.Meeting{Day: "Monday", Start: "08:30", End: "10:30"}, models.Meeting{Day: "Monday", Start: "10:30", End: "12:29"}, false},
+		{"other day", models.Meeting{Day: "Monday", Start: "08:30", End: "10:29"}, models.Meeting{Day: "Tuesday", Start: "08:30", End: "10:29"}, false},
+		// "8:30" sorts after "10:29" as a string
+		{"unpadded hour overlapping", models.Meeting{Day: "Monday", Start: "8:30", End: "10:29"}, models.Meeting{Day: "Monday", Start: "9:30", End: "11:29"}, true},
+		{"unpadded hour before", models.Meeting{Day: "Monday", Start: "8:30", End: "9:29"}, models.Meeting{Day: "Monday", Start: "10:30", End: "12:29"}, false},
+		{"unpadded and padded", models.Meeting{Day: "Monday", Start: "8:30", End: "10:29"}, models.Meeting{Day: "Monday", Start: "09:30", End: "11:29"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("overlap(a, b) = %v, want %v", got, tt.want)
+			}
+			if got := overlap(tt.b, tt.a); got != tt.want {
+				t.Errorf("overlap(b, a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	index := newCatalogIndex(&models.CourseCatalog{Courses: []models.Course{
+		{Code: "BLG 101E", Sections: []models.Section{
+			{CRN: "11", Meetings: []models.Meeting{{Day: "Monday", Start: "8:30", End: "10:29"}, {Day: "Wednesday", Start: "13:30", End: "15:29"}}},
+		}},
+		{Code: "BLG 102E", Sections: []models.Section{
+			{CRN: "12", Meetings: []models.Meeting{{Day: "Wednesday", Start: "14:30", End: "16:29"}}},
+		}},
+		{Code: "MAT 103", Sections: []models.Section{
+			{CRN: "13", Meetings: []models.Meeting{{Day: "Monday", Start: "10:30", End: "12:29"}, {Day: "Friday"}}},
+			{CRN: "14", Meetings: []models.Meeting{{Day: "Monday", Start: "9:30", End: "11:29"}}},
+		}},
+	}})
+
+	meeting := func(crn, code string, i int) models.SectionMeeting {
+		section, _, _ := index.section(crn)
+		return models.SectionMeeting{CRN: crn, Code: code, Meeting: section.Meetings[i]}
+	}
+	tests := []struct {
+		name string
+		crns []string
+		want *models.ConflictReport
+	}{
+		{"no conflicts", []string{"11", "13"}, &models.ConflictReport{Conflicts: []models.Conflict{}, UnknownCRNs: []string{}}},
+		{"second meeting", []string{"11", "12"}, &models.ConflictReport{
+			Conflicts:   []models.Conflict{{First: meeting("11", "BLG 101E", 1), Second: meeting("12", "BLG 102E", 0)}},
+			UnknownCRNs: []string{},
+		}},
+		{"unpadded hours", []string{"14", "11", "13"}, &models.ConflictReport{
+			Conflicts: []models.Conflict{
+				{First: meeting("14", "MAT 103", 0), Second: meeting("11", "BLG 101E", 0)},
+				{First: meeting("14", "MAT 103", 0), Second: meeting("13", "MAT 103", 0)},
+			},
+			UnknownCRNs: []string{},
+		}},
+		{"duplicates and unknown CRNs", []string{"11", "11", "99"}, &models.ConflictReport{Conflicts: []models.Conflict{}, UnknownCRNs: []string{"99"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findConflicts(index, tt.crns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findConflicts() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
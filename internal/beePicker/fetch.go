@@ -0,0 +1,155 @@
+package beepicker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CatalogConfig configures how the course catalog is fetched and kept.
+type CatalogConfig struct {
+	// Sources are the names of the course sources ("github", "sis", "local") in the
+	// order they are tried, the next one is used when a source is down
+	Sources []string
+	// LocalDir is the folder the local source reads, laid out like the scraper repository
+	LocalDir string
+	// Dir persists the catalog across restarts, empty keeps it in memory only
+	Dir string
+	// Workers is the number of branch files downloaded at the same time
+	Workers int
+	// RequestTimeout bounds every single download attempt
+	RequestTimeout time.Duration
+	// Retries is the number of extra attempts for a download that failed temporarily
+	Retries int
+	// MaxFailedBranches is the number of branches a refresh may miss and still
+	// replace a catalog that is already being served
+	MaxFailedBranches int
+	// GitHubToken authenticates the GitHub API requests listing the scraper runs, without
+	// it GitHub allows 60 requests an hour per IP address
+	GitHubToken string
+}
+
+// retryBackoff is the wait before the first retry, it doubles with every further retry.
+const retryBackoff = 500 * time.Millisecond
+
+// FetchReport describes a download of the branch files.
+type FetchReport struct {
+	Branches int             `json:"branches"`
+	Failed   []BranchFailure `json:"failed"`
+}
+
+type BranchFailure struct {
+	Branch string `json:"branch"`
+	Error  string `json:"error"`
+}
+
+// Complete reports whether every branch was downloaded.
+func (r *FetchReport) Complete() bool {
+	return len(r.Failed) == 0
+}
+
+// FailedBranches lists the branch codes that could not be downloaded.
+func (r *FetchReport) FailedBranches() []string {
+	branches := make([]string, len(r.Failed))
+	for i, failure := range r.Failed {
+		branches[i] = failure.Branch
+	}
+	return branches
+}
+
+// statusError is an unexpected HTTP status of a download.
+type statusError struct {
+	url    string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s answered with status %d %s", e.url, e.status, http.StatusText(e.status))
+}
+
+// temporary reports whether a failed download is worth retrying.
+func temporary(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.status == http.StatusTooManyRequests || status.status >= 500
+	}
+	return true
+}
+
+// fetcher downloads course data for the sources with the limits of CatalogConfig.
+type fetcher struct {
+	config CatalogConfig
+	store  *diskStore
+}
+
+// get downloads url, giving every attempt RequestTimeout and retrying temporary
+// failures with exponential backoff.
+func (f *fetcher) get(ctx context.Context, url string) ([]byte, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, f.config.RequestTimeout)
+		body, err := f.store.get(attemptCtx, url)
+		cancel()
+		if err == nil || attempt >= f.config.Retries || !temporary(err) {
+			return body, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// branches runs fetch for every branch code with a bounded number of workers.
+// Branches that fail are left out and listed in the report, so the caller can
+// decide whether the result is good enough.
+func (f *fetcher) branches(ctx context.Context, codes []string, fetch func(ctx context.Context, code string) ([]scraperSection, error)) ([]scraperSection, *FetchReport) {
+	var (
+		mu          sync.Mutex
+		allCourses  []scraperSection
+		report      = &FetchReport{Branches: len(codes), Failed: []BranchFailure{}}
+		queue       = make(chan string)
+		wg          sync.WaitGroup
+		workerCount = max(1, min(f.config.Workers, len(codes)))
+	)
+
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for code := range queue {
+				sections, err := fetch(ctx, code)
+
+				mu.Lock()
+				if err != nil {
+					report.Failed = append(report.Failed, BranchFailure{Branch: code, Error: err.Error()})
+				} else {
+					allCourses = append(allCourses, sections...)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, code := range codes {
+		queue <- code
+	}
+	close(queue)
+	wg.Wait()
+
+	sort.Slice(report.Failed, func(i, j int) bool { return report.Failed[i].Branch < report.Failed[j].Branch })
+	if !report.Complete() {
+		log.Printf("Course data of %d/%d branches could not be downloaded: %s",
+			len(report.Failed), report.Branches, strings.Join(report.FailedBranches(), ", "))
+	}
+	return allCourses, report
+}
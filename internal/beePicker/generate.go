@@ -0,0 +1,373 @@
+package beepicker
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
+)
+
+// GenerateRequest asks for the conflict-free schedules of a course wish list.
+type GenerateRequest struct {
+	// Courses are course codes, e.g. "BLG 312E". Kepler takes at most 12 CRNs at once.
+	Courses     []string            `json:"courses" binding:"required,min=1,max=12"`
+	Preferences SchedulePreferences `json:"preferences"`
+	// Limit is the number of schedules returned, 20 by default
+	Limit int `json:"limit" binding:"min=0,max=100"`
+	// Save stores the returned schedules as "<Save> 1", "<Save> 2", ... in the user's schedules,
+	// it fails if one of the names is taken
+	Save string `json:"save"`
+
+	// Term or Snapshot plan with an older catalog, see /beePicker/terms
+	Term     string `json:"term"`
+	Snapshot string `json:"snapshot"`
+}
+
+// SchedulePreferences rank the generated schedules, they never rule a schedule out.
+type SchedulePreferences struct {
+	// NotBefore ("15:04") is the preferred earliest start of a meeting
+	NotBefore string `json:"not_before"`
+	// FreeDays are the days to keep free, without them every free weekday counts
+	FreeDays []string `json:"free_days"`
+	// Instructors are parts of the names of preferred instructors
+	Instructors []string `json:"instructors"`
+}
+
+// Score weights of the schedule preferences. Gaps and full sections always cost points.
+const (
+	earlyMeetingPenalty      = 10
+	freeDayBonus             = 20
+	gapHourPenalty           = 5
+	preferredInstructorBonus = 10
+	fullSectionPenalty       = 15
+)
+
+// maxSearchNodes bounds the search, wish lists of many courses with many sections
+// have millions of schedules. Every section tried counts as a node.
+const maxSearchNodes = 50000
+
+var (
+	weekdays           = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+	errBadNotBefore    = apperr.ErrBadRequest.WithMessage("not_before must look like 15:04")
+	errUnknownFreeDays = apperr.ErrBadRequest.WithMessage("free_days must be day names")
+)
+
+// GenerateService finds every schedule of the wish list without overlapping meetings
+// and returns the best ones, saving them to the session user's schedules when asked to.
+func (s *Service) GenerateService(sess *session.Session, req GenerateRequest) (*models.GeneratedSchedules, error) {
+	prefs, err := newSchedulePreferences(req.Preferences)
+	if err != nil {
+		return nil, err
+	}
+	index, err := s.selectIndex(req.Term, req.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	courses, err := index.wishList(req.Courses)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = 20
+	}
+	// Every schedule is scored as it is found and only the best ones are kept,
+	// so the ranking does not depend on the order of the search
+	result := &models.GeneratedSchedules{Schedules: []models.GeneratedSchedule{}}
+	g := newScheduleGenerator(courses)
+	g.found = func(chosen []int) {
+		result.Total++
+		schedule := prefs.score(g.schedule(chosen))
+		i := sort.Search(len(result.Schedules), func(i int) bool { return ranksBefore(schedule, result.Schedules[i]) })
+		if i < limit {
+			result.Schedules = slices.Insert(result.Schedules, i, schedule)
+			result.Schedules = result.Schedules[:min(limit, len(result.Schedules))]
+		}
+	}
+	g.search(g.domains)
+	result.Truncated = g.truncated
+
+	if req.Save != "" {
+		if err := s.saveGeneratedSchedules(sess.Username(), req.Save, result.Schedules); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ranksBefore orders schedules by score, ties are broken by CRNs so the same wish
+// list always ranks the same way.
+func ranksBefore(a, b models.GeneratedSchedule) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return slices.Compare(a.CRNs, b.CRNs) < 0
+}
+
+// wishList finds the courses of the wish list, codes are matched ignoring case and spacing.
+func (index *catalogIndex) wishList(codes []string) ([]*models.Course, error) {
+	var courses []*models.Course
+	seen := make(map[*models.Course]bool)
+	for _, code := range codes {
+		branch, number := splitCourseCode(code)
+		var found *models.Course
+		for _, i := range index.byBranch[strings.ToLower(branch)] {
+			if strings.EqualFold(index.catalog.Courses[i].Number, number) {
+				found = &index.catalog.Courses[i]
+				break
+			}
+		}
+		if found == nil {
+			return nil, apperr.ErrNotFound.WithMessage(fmt.Sprintf("course %s is not in the catalog", code))
+		}
+		if !seen[found] {
+			seen[found] = true
+			courses = append(courses, found)
+		}
+	}
+	return courses, nil
+}
+
+// scheduleGenerator enumerates the schedules by backtracking over the courses. Every
+// candidate section is numbered; a course's domain holds the sections that do not
+// conflict with the sections chosen so far.
+type scheduleGenerator struct {
+	sections  []models.ScheduledSection
+	conflicts [][]bool
+	domains   [][]int
+	chosen    []int
+	// found is called with the sections of every schedule
+	found func(chosen []int)
+	// nodes counts the sections tried, the search stops after maxSearchNodes
+	nodes     int
+	truncated bool
+}
+
+func newScheduleGenerator(courses []*models.Course) *scheduleGenerator {
+	g := &scheduleGenerator{}
+	for _, course := range courses {
+		var domain []int
+		for _, section := range course.Sections {
+			domain = append(domain, len(g.sections))
+			g.sections = append(g.sections, models.ScheduledSection{Code: course.Code, Title: course.Title, Section: section})
+		}
+		g.domains = append(g.domains, domain)
+	}
+
+	g.conflicts = make([][]bool, len(g.sections))
+	for i := range g.sections {
+		g.conflicts[i] = make([]bool, len(g.sections))
+	}
+	for i := range g.sections {
+		for j := i + 1; j < len(g.sections); j++ {
+			if sectionsOverlap(&g.sections[i].Section, &g.sections[j].Section) {
+				g.conflicts[i][j], g.conflicts[j][i] = true, true
+			}
+		}
+	}
+	return g
+}
+
+// search picks a section of the course with the fewest sections left and drops the
+// sections conflicting with it from the other courses. A course left without
+// sections ends the branch before it is explored any further.
+func (g *scheduleGenerator) search(domains [][]int) {
+	if len(domains) == 0 {
+		g.found(g.chosen)
+		return
+	}
+
+	next := 0
+	for i := range domains {
+		if len(domains[i]) < len(domains[next]) {
+			next = i
+		}
+	}
+
+candidates:
+	for _, candidate := range domains[next] {
+		if g.nodes++; g.nodes > maxSearchNodes {
+			g.truncated = true
+			return
+		}
+		rest := make([][]int, 0, len(domains)-1)
+		for i, domain := range domains {
+			if i == next {
+				continue
+			}
+			var left []int
+			for _, other := range domain {
+				if !g.conflicts[candidate][other] {
+					left = append(left, other)
+				}
+			}
+			if len(left) == 0 {
+				continue candidates
+			}
+			rest = append(rest, left)
+		}
+
+		g.chosen = append(g.chosen, candidate)
+		g.search(rest)
+		g.chosen = g.chosen[:len(g.chosen)-1]
+		if g.truncated {
+			return
+		}
+	}
+}
+
+// schedule returns the sections of a found schedule in course code order.
+func (g *scheduleGenerator) schedule(found []int) []models.ScheduledSection {
+	sections := make([]models.ScheduledSection, len(found))
+	for i, n := range found {
+		sections[i] = g.sections[n]
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Code < sections[j].Code })
+	return sections
+}
+
+// sectionsOverlap reports whether any meetings of two sections overlap, meetings
+// without times never do.
+func sectionsOverlap(a, b *models.Section) bool {
+	for _, x := range a.Meetings {
+		for _, y := range b.Meetings {
+			if x.Start != "" && x.End != "" && y.Start != "" && y.End != "" && overlap(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schedulePreferences are SchedulePreferences validated and normalized for scoring.
+type schedulePreferences struct {
+	// notBefore is in minutes since midnight, 0 when there is no preference
+	notBefore   int
+	freeDays    []string
+	instructors []string
+}
+
+func newSchedulePreferences(p SchedulePreferences) (*schedulePreferences, error) {
+	prefs := &schedulePreferences{freeDays: weekdays}
+	if p.NotBefore != "" {
+		t, err := time.Parse("15:04", p.NotBefore)
+		if err != nil {
+			return nil, errBadNotBefore
+		}
+		prefs.notBefore = t.Hour()*60 + t.Minute()
+	}
+	if len(p.FreeDays) > 0 {
+		prefs.freeDays = nil
+		for _, day := range p.FreeDays {
+			english := ""
+			for _, known := range turkishDays {
+				if strings.EqualFold(known, englishDay(day)) {
+					english = known
+				}
+			}
+			if english == "" {
+				return nil, errUnknownFreeDays
+			}
+			prefs.freeDays = append(prefs.freeDays, english)
+		}
+	}
+	for _, instructor := range p.Instructors {
+		if instructor = strings.Join(strings.Fields(normalize(instructor)), " "); instructor != "" {
+			prefs.instructors = append(prefs.instructors, instructor)
+		}
+	}
+	return prefs, nil
+}
+
+// score rates a schedule by the preferences, higher is better.
+func (p *schedulePreferences) score(sections []models.ScheduledSection) models.GeneratedSchedule {
+	schedule := models.GeneratedSchedule{Sections: sections, FreeDays: []string{}}
+	days := make(map[string][]models.Meeting)
+	for _, section := range sections {
+		schedule.CRNs = append(schedule.CRNs, section.CRN)
+		for _, meeting := range section.Meetings {
+			if meeting.Start == "" || meeting.End == "" {
+				continue
+			}
+			days[meeting.Day] = append(days[meeting.Day], meeting)
+			if clockMinutes(meeting.Start) < p.notBefore {
+				schedule.EarlyMeetings++
+			}
+		}
+		for _, instructor := range p.instructors {
+			if containsNormalized(section.Instructors, instructor) {
+				schedule.PreferredInstructors++
+				break
+			}
+		}
+		if section.Capacity > 0 && section.Enrolled >= section.Capacity {
+			schedule.FullSections++
+		}
+	}
+
+	freeDays := 0
+	for _, day := range weekdays {
+		if len(days[day]) == 0 {
+			schedule.FreeDays = append(schedule.FreeDays, day)
+			if slices.Contains(p.freeDays, day) {
+				freeDays++
+			}
+		}
+	}
+	for _, meetings := range days {
+		sort.Slice(meetings, func(i, j int) bool { return clockMinutes(meetings[i].Start) < clockMinutes(meetings[j].Start) })
+		for i := 1; i < len(meetings); i++ {
+			// Meetings end a minute before the hour, e.g. 10:29 to 13:30 is a 3 hour gap
+			if gap := clockMinutes(meetings[i].Start) - clockMinutes(meetings[i-1].End); gap > 0 {
+				schedule.GapHours += gap / 60
+			}
+		}
+	}
+
+	schedule.Score = freeDays*freeDayBonus +
+		schedule.PreferredInstructors*preferredInstructorBonus -
+		schedule.EarlyMeetings*earlyMeetingPenalty -
+		schedule.GapHours*gapHourPenalty -
+		schedule.FullSections*fullSectionPenalty
+	return schedule
+}
+
+// clockMinutes turns "15:04" into minutes since midnight.
+func clockMinutes(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// saveGeneratedSchedules stores the schedules as "<prefix> 1", "<prefix> 2", ...,
+// none of them is saved if one of the names is taken.
+func (s *Service) saveGeneratedSchedules(user, prefix string, schedules []models.GeneratedSchedule) error {
+	saved := make([]utils.Schedule, len(schedules))
+	for i := range schedules {
+		saved[i] = utils.Schedule{Name: fmt.Sprintf("%s %d", prefix, i+1), ECRN: []int{}, SCRN: []int{}}
+		for _, crn := range schedules[i].CRNs {
+			n, err := strconv.Atoi(crn)
+			if err != nil {
+				return errSaveSchedules.Wrap(fmt.Errorf("CRN %q is not a number", crn))
+			}
+			saved[i].ECRN = append(saved[i].ECRN, n)
+		}
+	}
+	if err := s.schedules.save(user, saved...); err != nil {
+		return err
+	}
+	for i := range schedules {
+		schedules[i].Name = saved[i].Name
+	}
+	return nil
+}
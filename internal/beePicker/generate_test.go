@@ -0,0 +1,139 @@
+package beepicker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// useCatalog serves courses as the current catalog for the rest of the test.
+func useCatalog(t *testing.T, courses []models.Course) {
+	previous := cache.Swap(newCatalogIndex(&models.CourseCatalog{Courses: courses}))
+	cacheMutex.Lock()
+	previousTimestamp := cacheTimestamp
+	cacheTimestamp = time.Now()
+	cacheMutex.Unlock()
+	t.Cleanup(func() {
+		cache.Store(previous)
+		cacheMutex.Lock()
+		cacheTimestamp = previousTimestamp
+		cacheMutex.Unlock()
+	})
+}
+
+func course(code string, sections ...models.Section) models.Course {
+	branch, number := splitCourseCode(code)
+	return models.Course{Code: code, Branch: branch, Number: number, Sections: sections}
+}
+
+func section(crn, day, start, end string) models.Section {
+	return models.Section{CRN: crn, Capacity: 50, Meetings: []models.Meeting{{Day: day, Start: start, End: end}}}
+}
+
+// checkConflictFree fails the test if two sections of a schedule overlap.
+func checkConflictFree(t *testing.T, schedules []models.GeneratedSchedule) {
+	t.Helper()
+	for _, schedule := range schedules {
+		for i := range schedule.Sections {
+			for j := i + 1; j < len(schedule.Sections); j++ {
+				if sectionsOverlap(&schedule.Sections[i].Section, &schedule.Sections[j].Section) {
+					t.Errorf("schedule %v: %s and %s overlap", schedule.CRNs, schedule.Sections[i].CRN, schedule.Sections[j].CRN)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	useCatalog(t, []models.Course{
+		course("BLG 101E", section("11", "Monday", "8:30", "10:29"), section("12", "Tuesday", "10:30", "12:29")),
+		course("BLG 102E", section("21", "Monday", "9:30", "11:29"), section("22", "Wednesday", "13:30", "15:29")),
+		course("CHM 101", section("41", "Friday", "8:30", "10:29")),
+		course("CHM 102", section("51", "Friday", "9:30", "11:29"), section("52", "Friday", "10:00", "12:29")),
+	})
+	s := &Service{}
+
+	tests := []struct {
+		name    string
+		req     GenerateRequest
+		total   int
+		want    [][]string
+		wantErr error
+	}{
+		// (11, 21) overlap on Monday morning; the rest all keep three weekdays free and tie
+		{"conflict free", GenerateRequest{Courses: []string{"BLG 101E", "blg102e"}}, 3, [][]string{{"11", "22"}, {"12", "21"}, {"12", "22"}}, nil},
+		{"preferences", GenerateRequest{Courses: []string{"BLG 101E", "BLG 102E"}, Preferences: SchedulePreferences{NotBefore: "09:00"}}, 3, [][]string{{"12", "21"}, {"12", "22"}, {"11", "22"}}, nil},
+		{"limit", GenerateRequest{Courses: []string{"BLG 101E", "BLG 102E"}, Limit: 1}, 3, [][]string{{"11", "22"}}, nil},
+		{"duplicate courses", GenerateRequest{Courses: []string{"CHM 101", "CHM 101"}}, 1, [][]string{{"41"}}, nil},
+		{"no solution", GenerateRequest{Courses: []string{"BLG 101E", "CHM 101", "CHM 102"}}, 0, [][]string{}, nil},
+		{"unknown course", GenerateRequest{Courses: []string{"BLG 999"}}, 0, nil, apperr.ErrNotFound},
+		{"bad not_before", GenerateRequest{Courses: []string{"BLG 101E"}, Preferences: SchedulePreferences{NotBefore: "9am"}}, 0, nil, apperr.ErrBadRequest},
+		{"unknown free day", GenerateRequest{Courses: []string{"BLG 101E"}, Preferences: SchedulePreferences{FreeDays: []string{"Someday"}}}, 0, nil, apperr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.GenerateService(nil, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateService() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := [][]string{}
+			for _, schedule := range result.Schedules {
+				got = append(got, schedule.CRNs)
+			}
+			if result.Total != tt.total || result.Truncated || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateService() = %d schedules (truncated %v) %v, want %d %v", result.Total, result.Truncated, got, tt.total, tt.want)
+			}
+			checkConflictFree(t, result.Schedules)
+		})
+	}
+}
+
+// Twelve courses of eight sections that never conflict have 8^12 schedules,
+// the search stops at maxSearchNodes and still returns the best ones found.
+func TestGenerateNodeCap(t *testing.T) {
+	var courses []models.Course
+	var codes []string
+	for c := range 12 {
+		code := fmt.Sprintf("BLG %d", 101+c)
+		day, hour := weekdays[c%5], 8+2*(c/5)
+		var sections []models.Section
+		for n := range 8 {
+			sections = append(sections, section(fmt.Sprintf("%d%d", c+10, n), day, fmt.Sprintf("%d:30", hour), fmt.Sprintf("%d:29", hour+2)))
+		}
+		courses = append(courses, course(code, sections...))
+		codes = append(codes, code)
+	}
+	useCatalog(t, courses)
+
+	started := time.Now()
+	result, err := (&Service{}).GenerateService(nil, GenerateRequest{Courses: codes, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated {
+		t.Error("Truncated = false, want the search to stop early")
+	}
+	if result.Total == 0 || result.Total > maxSearchNodes {
+		t.Errorf("Total = %d, want between 1 and %d", result.Total, maxSearchNodes)
+	}
+	if len(result.Schedules) != 5 {
+		t.Errorf("got %d schedules, want 5", len(result.Schedules))
+	}
+	for _, schedule := range result.Schedules {
+		if len(schedule.CRNs) != 12 {
+			t.Errorf("schedule %v does not cover every course", schedule.CRNs)
+		}
+	}
+	checkConflictFree(t, result.Schedules)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("the capped search took %s", elapsed)
+	}
+}
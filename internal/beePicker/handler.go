@@ -1,8 +1,14 @@
 package beepicker
 
 import (
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,23 +23,223 @@ func NewHandler(service *Service) *Handler {
 // CourseHandler handles the request for retrieving courses from the BeePicker.
 // @Tags BeePicker
 // @Summary Retrieves courses from the BeePicker.
+// @Description Without query parameters the whole catalog is returned in one page.
 // @Produce json
+// @Param branch query string false "Branch code, e.g. BLG"
+// @Param number query string false "Course number, e.g. 101E"
+// @Param crn query string false "CRN"
+// @Param instructor query string false "Part of an instructor name"
+// @Param day query string false "Day name in English or Turkish"
+// @Param from query string false "Earliest start of every meeting (15:04)"
+// @Param to query string false "Latest end of every meeting (15:04)"
+// @Param building query string false "Building code"
+// @Param available query bool false "Only sections with seats left"
+// @Param min_seats query int false "Only sections with at least this many seats left"
+// @Param language query string false "Teaching language" Enums(en, tr)
+// @Param sort query string false "Sort key, prefix with - for descending" Enums(code, -code, title, -title, credits, -credits, seats, -seats)
+// @Param limit query int false "Page size, 0 returns every course"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param term query string false "Serve the newest snapshot whose name starts with this term"
+// @Param snapshot query string false "Serve this snapshot, see /beePicker/terms"
+// @Success 200 {object} models.CoursePage
+// @Header 200 {integer} Age "Age of the catalog snapshot in seconds"
+// @Failure 400 {object} apperr.Envelope "Invalid query"
+// @Failure 404 {object} apperr.Envelope "Unknown term or snapshot"
+// @Failure 502 {object} apperr.Envelope "Course catalog unavailable"
 // @Router /beePicker/courses [get]
 func (h *Handler) CourseHandler(c *gin.Context) {
+	var query CourseQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, fetchedAt, err := h.service.CourseQueryService(query)
+
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	setSnapshotAge(c, fetchedAt)
+
+	c.JSON(http.StatusOK, data)
+
+}
+
+// setSnapshotAge tells the client how old the catalog snapshot is in seconds.
+// It grows past the refresh interval only while no course source can be reached.
+func setSnapshotAge(c *gin.Context, fetchedAt time.Time) {
+	c.Header("Age", strconv.Itoa(int(time.Since(fetchedAt).Seconds())))
+}
+
+type searchQuery struct {
+	Query string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"min=0,max=100"`
+}
+
+// SearchHandler searches the course catalog by code and title.
+// @Tags BeePicker
+// @Summary Searches courses by code or title.
+// @Description Turkish letters, spacing and small typos are ignored, e.g. "isletim sistemleri" or "blg312e".
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Maximum number of results (default 20)"
+// @Success 200 {array} models.CourseMatch
+// @Header 200 {integer} Age "Age of the catalog snapshot in seconds"
+// @Failure 400 {object} apperr.Envelope "Missing query"
+// @Failure 502 {object} apperr.Envelope "Course catalog unavailable"
+// @Router /beePicker/search [get]
+func (h *Handler) SearchHandler(c *gin.Context) {
+	var query searchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	data, fetchedAt, err := h.service.SearchService(query.Query, query.Limit)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	setSnapshotAge(c, fetchedAt)
+	c.JSON(http.StatusOK, data)
+}
+
+// TermsHandler lists the course catalog snapshots that can be selected.
+// @Tags BeePicker
+// @Summary Lists the course catalog snapshots.
+// @Description Every snapshot is a course offering published by the course scraper, newest first.
+// @Produce json
+// @Success 200 {array} models.CatalogSnapshot
+// @Failure 501 {object} apperr.Envelope "The course source keeps no snapshots"
+// @Failure 502 {object} apperr.Envelope "Course source unavailable"
+// @Router /beePicker/terms [get]
+func (h *Handler) TermsHandler(c *gin.Context) {
+	data, err := h.service.SnapshotsService()
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
 
-	data, err := h.service.CourseService()
+type changesQuery struct {
+	From string `form:"from" binding:"required"`
+	To   string `form:"to"`
+}
 
+// ChangesHandler diffs two course catalog snapshots.
+// @Tags BeePicker
+// @Summary Lists the section changes between two catalog snapshots.
+// @Description Reports the added and removed CRNs and the instructor, time, room and capacity changes of the other sections.
+// @Produce json
+// @Param from query string true "Snapshot to compare from, see /beePicker/terms"
+// @Param to query string false "Snapshot to compare to, the current catalog by default"
+// @Success 200 {object} models.CatalogDiff
+// @Failure 400 {object} apperr.Envelope "Missing from"
+// @Failure 404 {object} apperr.Envelope "Unknown snapshot"
+// @Failure 502 {object} apperr.Envelope "Course source unavailable"
+// @Router /beePicker/changes [get]
+func (h *Handler) ChangesHandler(c *gin.Context) {
+	var query changesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, err := h.service.ChangesService(query.From, query.To)
 	if err != nil {
-		switch err.Error() {
-		case "error getting newest folder", "error getting course codes", "error getting course data":
-			c.JSON(http.StatusBadGateway, gin.H{"error": "cannot retrieve course information"})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// ChangeEventsHandler streams the catalog changes picked up by the cache refreshes.
+// @Tags BeePicker
+// @Summary Streams catalog changes as server-sent events.
+// @Description Every refresh that changes the catalog sends a "change" event holding a models.CatalogDiff.
+// @Produce text/event-stream
+// @Success 200 {object} models.CatalogDiff
+// @Router /beePicker/changes/events [get]
+func (h *Handler) ChangeEventsHandler(c *gin.Context) {
+	changes := catalogChanges.Subscribe()
+	defer catalogChanges.Unsubscribe(changes)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case diff := <-changes:
+			c.SSEvent("change", diff)
+			return true
+		case <-c.Request.Context().Done():
+			return false
 		}
+	})
+}
+
+type conflictsRequest struct {
+	CRNs []string `json:"crns" binding:"required,min=1,max=30"`
+}
+
+// ConflictsHandler reports the time conflicts of a set of CRNs.
+// @Tags BeePicker
+// @Summary Checks a set of CRNs for overlapping meetings.
+// @Description Every overlapping pair of meetings is reported, the same check runs before /beePicker/pick.
+// @Accept json
+// @Produce json
+// @Param request body conflictsRequest true "CRNs to check"
+// @Success 200 {object} models.ConflictReport
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 502 {object} apperr.Envelope "Course catalog unavailable"
+// @Router /beePicker/conflicts [post]
+func (h *Handler) ConflictsHandler(c *gin.Context) {
+	var req conflictsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
 	}
 
+	data, err := h.service.ConflictService(req.CRNs)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, data)
+}
 
+// GenerateHandler generates the conflict-free schedules of a course wish list.
+// @Tags BeePicker
+// @Summary Generates conflict-free schedules from a course wish list.
+// @Description Every combination of sections without overlapping meetings is ranked by the preferences:
+// @Description meetings before not_before, free days, idle hours between meetings, preferred instructors and full sections.
+// @Description With save the returned schedules are stored in the user's schedules, unless a name is taken.
+// @Description A wish list with very many schedules is searched only partly, the result is then truncated.
+// @Accept json
+// @Produce json
+// @Param request body GenerateRequest true "Course wish list and preferences"
+// @Success 200 {object} models.GeneratedSchedules
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Unknown course, term or snapshot"
+// @Failure 409 {object} apperr.Envelope "A schedule with a saved name already exists"
+// @Failure 502 {object} apperr.Envelope "Course catalog unavailable"
+// @Router /beePicker/generate [post]
+func (h *Handler) GenerateHandler(c *gin.Context) {
+	var req GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, err := h.service.GenerateService(session.FromContext(c), req)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
 }
 
 type pickRequest struct {
@@ -47,22 +253,194 @@ type pickRequest struct {
 // @Produce json
 // @Param request body pickRequest true "Request body containing the course codes"
 // @Success 200 {object} string "Picking successful"
-// @Failure 400 {object} string "Bad request"
-// @Failure 500 {object} string "Internal server error"
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 409 {object} apperr.Envelope "The CRNs overlap"
+// @Failure 502 {object} apperr.Envelope "Kepler unavailable"
 // @Router /beePicker/pick [post]
 func (h *Handler) PickHandler(c *gin.Context) {
 	var req pickRequest
 
 	// JSON bind işlemi ve hata kontrolü
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
 		return
 	}
 
 	// CRN array'ini service katmanına iletme
-	data, err := h.service.PickService(req.CourseCodes)
+	data, err := h.service.PickService(session.FromContext(c), req.CourseCodes)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+type scheduleRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// ECRN are the CRNs to add, SCRN the CRNs to drop, at most 12 together
+	ECRN []int `json:"ECRN" binding:"max=12,dive,min=1"`
+	SCRN []int `json:"SCRN" binding:"max=12,dive,min=1"`
+}
+
+type scheduleCRNsRequest struct {
+	ECRN []int `json:"ECRN" binding:"max=12,dive,min=1"`
+	SCRN []int `json:"SCRN" binding:"max=12,dive,min=1"`
+}
+
+type renameScheduleRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// ListSchedulesHandler lists the user's saved schedules.
+// @Tags Schedules
+// @Summary Lists the saved schedules.
+// @Produce json
+// @Success 200 {object} utils.ScheduleList
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 500 {object} apperr.Envelope "Cannot read the schedules"
+// @Router /schedules [get]
+func (h *Handler) ListSchedulesHandler(c *gin.Context) {
+	data, err := h.service.ListSchedulesService(session.FromContext(c))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// GetScheduleHandler returns a saved schedule.
+// @Tags Schedules
+// @Summary Returns a saved schedule by name.
+// @Produce json
+// @Param name path string true "Schedule name"
+// @Success 200 {object} utils.Schedule
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Schedule not found"
+// @Router /schedules/{name} [get]
+func (h *Handler) GetScheduleHandler(c *gin.Context) {
+	data, err := h.service.GetScheduleService(session.FromContext(c), c.Param("name"))
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// CreateScheduleHandler saves a new schedule.
+// @Tags Schedules
+// @Summary Saves a new schedule.
+// @Accept json
+// @Produce json
+// @Param request body scheduleRequest true "Schedule name and the CRNs to add (ECRN) and drop (SCRN)"
+// @Success 201 {object} utils.Schedule
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 409 {object} apperr.Envelope "A schedule with this name already exists"
+// @Router /schedules [post]
+func (h *Handler) CreateScheduleHandler(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, err := h.service.CreateScheduleService(session.FromContext(c), utils.Schedule{Name: req.Name, ECRN: req.ECRN, SCRN: req.SCRN})
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, data)
+}
+
+// UpdateScheduleHandler replaces the CRNs of a saved schedule.
+// @Tags Schedules
+// @Summary Replaces the CRNs of a saved schedule.
+// @Accept json
+// @Produce json
+// @Param name path string true "Schedule name"
+// @Param request body scheduleCRNsRequest true "CRNs to add (ECRN) and drop (SCRN)"
+// @Success 200 {object} utils.Schedule
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Schedule not found"
+// @Router /schedules/{name} [put]
+func (h *Handler) UpdateScheduleHandler(c *gin.Context) {
+	var req scheduleCRNsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, err := h.service.UpdateScheduleService(session.FromContext(c), c.Param("name"), req.ECRN, req.SCRN)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// RenameScheduleHandler renames a saved schedule.
+// @Tags Schedules
+// @Summary Renames a saved schedule.
+// @Accept json
+// @Produce json
+// @Param name path string true "Schedule name"
+// @Param request body renameScheduleRequest true "New name"
+// @Success 200 {object} utils.Schedule
+// @Failure 400 {object} apperr.Envelope "Bad request"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Schedule not found"
+// @Failure 409 {object} apperr.Envelope "A schedule with the new name already exists"
+// @Router /schedules/{name} [patch]
+func (h *Handler) RenameScheduleHandler(c *gin.Context) {
+	var req renameScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Respond(c, apperr.ErrBadRequest.WithMessage(err.Error()))
+		return
+	}
+
+	data, err := h.service.RenameScheduleService(session.FromContext(c), c.Param("name"), req.Name)
+	if err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// DeleteScheduleHandler deletes a saved schedule.
+// @Tags Schedules
+// @Summary Deletes a saved schedule.
+// @Param name path string true "Schedule name"
+// @Success 204 "Deleted"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Schedule not found"
+// @Router /schedules/{name} [delete]
+func (h *Handler) DeleteScheduleHandler(c *gin.Context) {
+	if err := h.service.DeleteScheduleService(session.FromContext(c), c.Param("name")); err != nil {
+		apperr.Respond(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PickScheduleHandler registers the CRNs of a saved schedule.
+// @Tags BeePicker
+// @Summary Adds the ECRN and drops the SCRN CRNs of a saved schedule.
+// @Description Both lists are sent to Kepler in one request, so a course is only dropped together with the one replacing it.
+// @Produce json
+// @Param scheduleName path string true "Schedule name"
+// @Success 200 {object} map[string]kepler.CRNResult
+// @Failure 400 {object} apperr.Envelope "The schedule has no CRNs"
+// @Failure 401 {object} apperr.Envelope "Session expired"
+// @Failure 404 {object} apperr.Envelope "Schedule not found"
+// @Failure 409 {object} apperr.Envelope "The CRNs overlap"
+// @Failure 502 {object} apperr.Envelope "Kepler unavailable"
+// @Router /beePicker/pick/{scheduleName} [post]
+func (h *Handler) PickScheduleHandler(c *gin.Context) {
+	data, err := h.service.PickScheduleService(session.FromContext(c), c.Param("scheduleName"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperr.Respond(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, data)
@@ -0,0 +1,140 @@
+package beepicker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// scheduleRouter serves the schedule routes in an empty working directory, requests
+// run as the user named in the X-User header.
+func scheduleRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	gin.SetMode(gin.TestMode)
+	sessions := session.NewManager(time.Hour, 0, 0)
+	h := NewHandler(&Service{schedules: &scheduleRepository{}})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		sess, err := sessions.Create(&models.Person{Email: c.GetHeader("X-User")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.SetContext(c, sess)
+	})
+	r.GET("/schedules", h.ListSchedulesHandler)
+	r.POST("/schedules", h.CreateScheduleHandler)
+	r.PUT("/schedules/:name", h.UpdateScheduleHandler)
+	r.PATCH("/schedules/:name", h.RenameScheduleHandler)
+	r.POST("/beePicker/pick/:scheduleName", h.PickScheduleHandler)
+	return r
+}
+
+func serve(r *gin.Engine, user, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestScheduleCRNLimit(t *testing.T) {
+	r := scheduleRouter(t)
+	if w := serve(r, "ayse", http.MethodPost, "/schedules", `{"name":"Plan A","ECRN":[1],"SCRN":[]}`); w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		want         int
+	}{
+		{"twelve together", http.MethodPost, "/schedules", `{"name":"Twelve","ECRN":[1,2,3,4,5,6],"SCRN":[7,8,9,10,11,12]}`, http.StatusCreated},
+		{"thirteen together", http.MethodPost, "/schedules", `{"name":"Thirteen","ECRN":[1,2,3,4,5,6,7],"SCRN":[8,9,10,11,12,13]}`, http.StatusBadRequest},
+		{"thirteen to add", http.MethodPost, "/schedules", `{"name":"Thirteen","ECRN":[1,2,3,4,5,6,7,8,9,10,11,12,13]}`, http.StatusBadRequest},
+		{"update to twelve", http.MethodPut, "/schedules/Plan%20A", `{"ECRN":[1,2,3,4,5,6,7,8,9,10,11,12]}`, http.StatusOK},
+		{"update to thirteen", http.MethodPut, "/schedules/Plan%20A", `{"ECRN":[1,2,3,4,5,6,7,8,9,10,11,12],"SCRN":[13]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(r, "ayse", tt.method, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	list, err := utils.GetSchedulesOf("ayse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, schedule := range list.Schedules {
+		if len(schedule.ECRN)+len(schedule.SCRN) > maxRegisterCRNs {
+			t.Errorf("schedule %q was saved with %d CRNs", schedule.Name, len(schedule.ECRN)+len(schedule.SCRN))
+		}
+	}
+}
+
+// Schedules saved before the limit was checked are refused before Kepler is asked.
+func TestPickOverCRNLimit(t *testing.T) {
+	r := scheduleRouter(t)
+	err := utils.SaveSchedulesOf("ayse", utils.ScheduleList{Schedules: []utils.Schedule{
+		{Name: "Old", ECRN: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, SCRN: []int{11, 12, 13}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, "ayse", http.MethodPost, "/beePicker/pick/Old", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+	}
+}
+
+func TestScheduleDuplicateNames(t *testing.T) {
+	r := scheduleRouter(t)
+	for _, name := range []string{"Plan A", "Plan B"} {
+		if w := serve(r, "ayse", http.MethodPost, "/schedules", `{"name":"`+name+`","ECRN":[1]}`); w.Code != http.StatusCreated {
+			t.Fatalf("create %s: status %d: %s", name, w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		user         string
+		method, path string
+		body         string
+		want         int
+	}{
+		{"same name", "ayse", http.MethodPost, "/schedules", `{"name":"Plan A","ECRN":[2]}`, http.StatusConflict},
+		{"same name with spaces", "ayse", http.MethodPost, "/schedules", `{"name":"  Plan A ","ECRN":[2]}`, http.StatusConflict},
+		{"rename to a taken name", "ayse", http.MethodPatch, "/schedules/Plan%20B", `{"name":"Plan A"}`, http.StatusConflict},
+		{"rename to its own name", "ayse", http.MethodPatch, "/schedules/Plan%20B", `{"name":"Plan B"}`, http.StatusOK},
+		{"same name of another user", "Mehmet@itu.edu.tr", http.MethodPost, "/schedules", `{"name":"Plan A","ECRN":[3]}`, http.StatusCreated},
+		{"other spelling of the same user", "AYSE@itu.edu.tr", http.MethodPost, "/schedules", `{"name":"Plan A","ECRN":[4]}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(r, tt.user, tt.method, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	w := serve(r, "ayse", http.MethodGet, "/schedules", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ECRN":[1]`) || strings.Contains(w.Body.String(), `"ECRN":[2]`) {
+		t.Errorf("the first Plan A was not kept: %s", w.Body)
+	}
+}
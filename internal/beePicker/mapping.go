@@ -0,0 +1,244 @@
+package beepicker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// scraperSection is one entry of the dersProgramList the course scraper publishes.
+// The scraper passes Kepler's values through, so numbers sometimes arrive as strings
+// and sections meeting more than once carry one value per meeting, either as an
+// array or joined with <br>.
+type scraperSection struct {
+	CRN              flexString `json:"crn"`
+	CourseCode       flexString `json:"dersKodu"`
+	Title            flexString `json:"dersAdi"`
+	TeachingMethod   flexString `json:"ogretimYontemi"`
+	Instructors      flexList   `json:"adSoyad"`
+	DaysEN           flexList   `json:"gunAdiEN"`
+	DaysTR           flexList   `json:"gunAdiTR"`
+	Starts           flexList   `json:"baslangicSaati"`
+	Ends             flexList   `json:"bitisSaati"`
+	Buildings        flexList   `json:"binaKodu"`
+	Rooms            flexList   `json:"mekanAdi"`
+	Capacity         flexNumber `json:"kontenjan"`
+	Enrolled         flexNumber `json:"ogrenciSayisi"`
+	Credits          flexNumber `json:"kredi"`
+	Programs         flexString `json:"sinifProgram"`
+	Prerequisites    flexString `json:"onSart"`
+	ClassRestriction flexString `json:"sinifOnsart"`
+	Reservation      flexString `json:"rezervasyon"`
+}
+
+// flexString accepts any JSON scalar.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = flexString(strings.TrimSpace(value))
+	default:
+		*s = flexString(fmt.Sprint(value))
+	}
+	return nil
+}
+
+// flexNumber accepts a number or a numeric string.
+type flexNumber float64
+
+func (n *flexNumber) UnmarshalJSON(data []byte) error {
+	var s flexString
+	if err := s.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(string(s), ",", "."), 64)
+	*n = flexNumber(value)
+	return nil
+}
+
+var multiValueSeparator = regexp.MustCompile(`(?i)<br\s*/?>|\n`)
+
+// flexList accepts an array or a string holding one value per line.
+type flexList []string
+
+func (l *flexList) UnmarshalJSON(data []byte) error {
+	var items []flexString
+	if err := json.Unmarshal(data, &items); err != nil {
+		var s flexString
+		if err := s.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		for _, item := range multiValueSeparator.Split(string(s), -1) {
+			items = append(items, flexString(strings.TrimSpace(item)))
+		}
+	}
+	*l = (*l)[:0]
+	for _, item := range items {
+		*l = append(*l, string(item))
+	}
+	return nil
+}
+
+// at returns the i-th value, a single value applies to every meeting.
+func (l flexList) at(i int) string {
+	switch {
+	case i < len(l):
+		return l[i]
+	case len(l) == 1:
+		return l[0]
+	}
+	return ""
+}
+
+var turkishDays = map[string]string{
+	"pazartesi": "Monday",
+	"salı":      "Tuesday",
+	"çarşamba":  "Wednesday",
+	"perşembe":  "Thursday",
+	"cuma":      "Friday",
+	"cumartesi": "Saturday",
+	"pazar":     "Sunday",
+}
+
+// toCatalog maps the scraper sections to courses, sorted by code and CRN.
+func toCatalog(sections []scraperSection) *models.CourseCatalog {
+	courses := make(map[string]*models.Course)
+	for _, entry := range sections {
+		if entry.CRN == "" {
+			continue
+		}
+		branch, number := splitCourseCode(string(entry.CourseCode))
+		code := strings.TrimSpace(branch + " " + number)
+		course, ok := courses[code]
+		if !ok {
+			course = &models.Course{
+				Code:     code,
+				Branch:   branch,
+				Number:   number,
+				Title:    string(entry.Title),
+				Language: courseLanguage(number),
+				Credits:  float64(entry.Credits),
+				Sections: []models.Section{},
+			}
+			courses[code] = course
+		}
+		course.Sections = append(course.Sections, toSection(entry))
+	}
+
+	catalog := &models.CourseCatalog{SchemaVersion: models.CourseSchemaVersion, Courses: []models.Course{}}
+	for _, course := range courses {
+		sort.Slice(course.Sections, func(i, j int) bool { return course.Sections[i].CRN < course.Sections[j].CRN })
+		catalog.Courses = append(catalog.Courses, *course)
+	}
+	sort.Slice(catalog.Courses, func(i, j int) bool { return catalog.Courses[i].Code < catalog.Courses[j].Code })
+	return catalog
+}
+
+func toSection(entry scraperSection) models.Section {
+	section := models.Section{
+		CRN:            string(entry.CRN),
+		Instructors:    []string{},
+		TeachingMethod: string(entry.TeachingMethod),
+		Meetings:       []models.Meeting{},
+		Capacity:       int(entry.Capacity),
+		Enrolled:       int(entry.Enrolled),
+		Restrictions: models.Restrictions{
+			Programs:      splitList(string(entry.Programs)),
+			Prerequisites: orNone(string(entry.Prerequisites)),
+			ClassLevel:    orNone(string(entry.ClassRestriction)),
+			Reservation:   orNone(string(entry.Reservation)),
+		},
+	}
+	for _, line := range entry.Instructors {
+		section.Instructors = append(section.Instructors, splitList(line)...)
+	}
+
+	meetings := max(len(entry.DaysEN), len(entry.DaysTR), len(entry.Starts))
+	for i := 0; i < meetings; i++ {
+		day := entry.DaysEN.at(i)
+		if day == "" {
+			day = turkishDays[strings.ToLower(entry.DaysTR.at(i))]
+		}
+		start, end := clockTime(entry.Starts.at(i)), clockTime(entry.Ends.at(i))
+		if day == "" && start == "" {
+			// Online or unscheduled sections have no meeting
+			continue
+		}
+		section.Meetings = append(section.Meetings, models.Meeting{
+			Day:      day,
+			Start:    start,
+			End:      end,
+			Building: entry.Buildings.at(i),
+			Room:     entry.Rooms.at(i),
+		})
+	}
+	return section
+}
+
+// splitCourseCode splits "BLG 101E" (or "BLG101E") into branch code and number.
+func splitCourseCode(code string) (string, string) {
+	code = strings.Join(strings.Fields(code), "")
+	i := strings.IndexFunc(code, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i <= 0 {
+		return code, ""
+	}
+	return code[:i], code[i:]
+}
+
+// courseLanguage tells English taught courses by the E suffix ITU gives their numbers.
+func courseLanguage(number string) string {
+	if strings.HasSuffix(strings.ToUpper(number), "E") {
+		return "en"
+	}
+	return "tr"
+}
+
+// splitList splits a comma separated list, dropping the placeholders Kepler uses for none.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = orNone(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// orNone returns "" for the placeholders Kepler uses for none.
+func orNone(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.Trim(value, "-") == "" {
+		return ""
+	}
+	return value
+}
+
+// clockTime shortens "08:30:00" (or "8:30:00") to "08:30", the padded form the
+// SIS source produces too, so the sources agree and their times compare as strings.
+func clockTime(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 {
+		return ""
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return ""
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
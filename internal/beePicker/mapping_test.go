@@ -0,0 +1,24 @@
+package beepicker
+
+import "testing"
+
+func TestClockTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"08:30:00", "08:30"},
+		{"8:30:00", "08:30"},
+		{"8:30", "08:30"},
+		{" 13:30:00 ", "13:30"},
+		{"", ""},
+		{"--", ""},
+		{"24:00:00", ""},
+		{"aa:30", ""},
+	}
+	for _, tt := range tests {
+		if got := clockTime(tt.value); got != tt.want {
+			t.Errorf("clockTime(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
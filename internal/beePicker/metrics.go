@@ -0,0 +1,48 @@
+package beepicker
+
+import (
+	"expvar"
+	"time"
+)
+
+// refreshMetrics are published under "beepicker_catalog" on /debug/vars.
+type refreshMetrics struct {
+	vars *expvar.Map
+}
+
+var catalogMetrics = newRefreshMetrics()
+
+func newRefreshMetrics() *refreshMetrics {
+	m := &refreshMetrics{vars: expvar.NewMap("beepicker_catalog")}
+	m.vars.Set("snapshot_age_seconds", expvar.Func(func() any {
+		if index := cache.Load(); index != nil {
+			return int(time.Since(index.fetchedAt).Seconds())
+		}
+		return nil
+	}))
+	return m
+}
+
+// record counts a finished refresh, the last error stays visible until a refresh succeeds.
+func (m *refreshMetrics) record(duration time.Duration, err error) {
+	m.vars.Add("refreshes", 1)
+	seconds := new(expvar.Float)
+	seconds.Set(duration.Seconds())
+	m.vars.Set("last_refresh_seconds", seconds)
+
+	lastError := new(expvar.String)
+	if err != nil {
+		m.vars.Add("refresh_failures", 1)
+		lastError.Set(err.Error())
+	} else {
+		success := new(expvar.String)
+		success.Set(time.Now().Format(time.RFC3339))
+		m.vars.Set("last_success", success)
+	}
+	m.vars.Set("last_error", lastError)
+}
+
+// recordReport publishes the branches the last refresh could not download.
+func (m *refreshMetrics) recordReport(report *FetchReport) {
+	m.vars.Set("last_failed_branches", expvar.Func(func() any { return report.FailedBranches() }))
+}
@@ -1 +1,180 @@
 package beepicker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
+)
+
+var (
+	errScheduleNotFound = apperr.ErrNotFound.WithMessage("schedule not found")
+	errScheduleExists   = apperr.ErrConflict.WithMessage("a schedule with this name already exists")
+	errScheduleName     = apperr.ErrBadRequest.WithMessage("the schedule name must not be blank")
+	errReadSchedules    = apperr.ErrInternal.WithMessage("cannot read the schedules")
+	errSaveSchedules    = apperr.ErrInternal.WithMessage("cannot save the schedules")
+)
+
+// scheduleRepository keeps the schedules of every user in their own file, see
+// utils.GetSchedulesOf. The files are read and written whole, so changes are made
+// one at a time to not lose each other.
+type scheduleRepository struct {
+	mu sync.Mutex
+}
+
+func (r *scheduleRepository) list(user string) (utils.ScheduleList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list, err := utils.GetSchedulesOf(user)
+	if err != nil {
+		return utils.ScheduleList{}, errReadSchedules.Wrap(err)
+	}
+	if list.Schedules == nil {
+		list.Schedules = []utils.Schedule{}
+	}
+	return list, nil
+}
+
+func (r *scheduleRepository) get(user, name string) (utils.Schedule, error) {
+	list, err := r.list(user)
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	if i := findSchedule(list, name); i >= 0 {
+		return list.Schedules[i], nil
+	}
+	return utils.Schedule{}, errScheduleNotFound
+}
+
+func (r *scheduleRepository) create(user string, schedule utils.Schedule) (utils.Schedule, error) {
+	schedule, err := cleanSchedule(schedule)
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	err = r.modify(user, func(list *utils.ScheduleList) error {
+		if findSchedule(*list, schedule.Name) >= 0 {
+			return errScheduleExists
+		}
+		list.Schedules = append(list.Schedules, schedule)
+		return nil
+	})
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// update replaces the CRNs of a schedule.
+func (r *scheduleRepository) update(user, name string, ecrn, scrn []int) (utils.Schedule, error) {
+	schedule, err := cleanSchedule(utils.Schedule{Name: name, ECRN: ecrn, SCRN: scrn})
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	err = r.modify(user, func(list *utils.ScheduleList) error {
+		i := findSchedule(*list, schedule.Name)
+		if i < 0 {
+			return errScheduleNotFound
+		}
+		list.Schedules[i] = schedule
+		return nil
+	})
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (r *scheduleRepository) rename(user, name, newName string) (utils.Schedule, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return utils.Schedule{}, errScheduleName
+	}
+	var schedule utils.Schedule
+	err := r.modify(user, func(list *utils.ScheduleList) error {
+		i := findSchedule(*list, name)
+		if i < 0 {
+			return errScheduleNotFound
+		}
+		if newName != name && findSchedule(*list, newName) >= 0 {
+			return errScheduleExists
+		}
+		list.Schedules[i].Name = newName
+		schedule = list.Schedules[i]
+		return nil
+	})
+	if err != nil {
+		return utils.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (r *scheduleRepository) delete(user, name string) error {
+	return r.modify(user, func(list *utils.ScheduleList) error {
+		i := findSchedule(*list, name)
+		if i < 0 {
+			return errScheduleNotFound
+		}
+		list.Schedules = append(list.Schedules[:i], list.Schedules[i+1:]...)
+		return nil
+	})
+}
+
+// save stores new schedules, all or none of them; a taken name fails them all.
+func (r *scheduleRepository) save(user string, schedules ...utils.Schedule) error {
+	return r.modify(user, func(list *utils.ScheduleList) error {
+		for _, schedule := range schedules {
+			if findSchedule(*list, schedule.Name) >= 0 {
+				return errScheduleExists.WithMessage(fmt.Sprintf("a schedule named %q already exists", schedule.Name))
+			}
+			list.Schedules = append(list.Schedules, schedule)
+		}
+		return nil
+	})
+}
+
+// modify reads the schedules, changes them with fn and writes them back unless fn fails.
+func (r *scheduleRepository) modify(user string, fn func(list *utils.ScheduleList) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list, err := utils.GetSchedulesOf(user)
+	if err != nil {
+		return errReadSchedules.Wrap(err)
+	}
+	if err := fn(&list); err != nil {
+		return err
+	}
+	if err := utils.SaveSchedulesOf(user, list); err != nil {
+		return errSaveSchedules.Wrap(err)
+	}
+	return nil
+}
+
+func findSchedule(list utils.ScheduleList, name string) int {
+	for i, schedule := range list.Schedules {
+		if schedule.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cleanSchedule trims the name and stores missing CRN lists as empty lists. A schedule
+// must fit in one Kepler registration call.
+func cleanSchedule(schedule utils.Schedule) (utils.Schedule, error) {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" {
+		return utils.Schedule{}, errScheduleName
+	}
+	if len(schedule.ECRN)+len(schedule.SCRN) > maxRegisterCRNs {
+		return utils.Schedule{}, errTooManyCRNs
+	}
+	if schedule.ECRN == nil {
+		schedule.ECRN = []int{}
+	}
+	if schedule.SCRN == nil {
+		schedule.SCRN = []int{}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,86 @@
+package beepicker
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
+)
+
+// maxRegisterCRNs is the number of CRNs Kepler adds and drops in one ders-kayit call.
+const maxRegisterCRNs = 12
+
+var (
+	errEmptySchedule = apperr.ErrBadRequest.WithMessage("the schedule has no CRNs to add or drop")
+	errTooManyCRNs   = apperr.ErrBadRequest.WithMessage(fmt.Sprintf("a schedule adds and drops at most %d CRNs together", maxRegisterCRNs))
+)
+
+// ListSchedulesService returns the saved schedules of the session's user,
+// every user only ever sees their own schedules.
+func (s *Service) ListSchedulesService(sess *session.Session) (utils.ScheduleList, error) {
+	return s.schedules.list(sess.Username())
+}
+
+func (s *Service) GetScheduleService(sess *session.Session, name string) (utils.Schedule, error) {
+	return s.schedules.get(sess.Username(), name)
+}
+
+// CreateScheduleService saves a new schedule, names are unique per user.
+func (s *Service) CreateScheduleService(sess *session.Session, schedule utils.Schedule) (utils.Schedule, error) {
+	return s.schedules.create(sess.Username(), schedule)
+}
+
+// UpdateScheduleService replaces the CRNs to add (ECRN) and drop (SCRN) of a schedule.
+func (s *Service) UpdateScheduleService(sess *session.Session, name string, ecrn, scrn []int) (utils.Schedule, error) {
+	return s.schedules.update(sess.Username(), name, ecrn, scrn)
+}
+
+func (s *Service) RenameScheduleService(sess *session.Session, name, newName string) (utils.Schedule, error) {
+	return s.schedules.rename(sess.Username(), name, newName)
+}
+
+func (s *Service) DeleteScheduleService(sess *session.Session, name string) error {
+	return s.schedules.delete(sess.Username(), name)
+}
+
+// PickScheduleService adds the ECRN and drops the SCRN CRNs of a saved schedule in a
+// single Kepler request, so a course is only dropped together with the one replacing it.
+func (s *Service) PickScheduleService(sess *session.Session, name string) (map[string]kepler.CRNResult, error) {
+	schedule, err := s.schedules.get(sess.Username(), name)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedule.ECRN)+len(schedule.SCRN) == 0 {
+		return nil, errEmptySchedule
+	}
+	// Schedules saved before the limit was checked may still have more
+	if len(schedule.ECRN)+len(schedule.SCRN) > maxRegisterCRNs {
+		return nil, errTooManyCRNs
+	}
+	ecrn, scrn := crnStrings(schedule.ECRN), crnStrings(schedule.SCRN)
+	if err := s.checkConflicts(ecrn); err != nil {
+		return nil, err
+	}
+
+	var response *kepler.RegisterResponse
+	err = sess.TokenKeeper().Do(func(token string) error {
+		var err error
+		response, err = s.kepler.Register(token, ecrn, scrn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergePickResponses([]*kepler.RegisterResponse{response}), nil
+}
+
+func crnStrings(crns []int) []string {
+	values := make([]string, len(crns))
+	for i, crn := range crns {
+		values[i] = strconv.Itoa(crn)
+	}
+	return values
+}
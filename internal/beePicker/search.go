@@ -0,0 +1,160 @@
+package beepicker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// turkishFolds maps the Turkish letters to the ASCII letters students type instead.
+var turkishFolds = strings.NewReplacer(
+	"ı", "i", "İ", "i", "I", "i",
+	"ş", "s", "Ş", "s",
+	"ğ", "g", "Ğ", "g",
+	"ü", "u", "Ü", "u",
+	"ö", "o", "Ö", "o",
+	"ç", "c", "Ç", "c",
+)
+
+// normalize lowercases s, folds the Turkish letters and replaces punctuation with spaces,
+// so "İşletim Sistemleri" and "isletim sistemleri" compare equal.
+func normalize(s string) string {
+	s = strings.ToLower(turkishFolds.Replace(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+}
+
+// searchEntry holds the normalized words of a course, built with the catalog index.
+type searchEntry struct {
+	// code is the course code without spaces, e.g. "blg312e"
+	code   string
+	tokens []string
+}
+
+func newSearchEntry(course *models.Course) searchEntry {
+	code := strings.Join(strings.Fields(normalize(course.Code)), "")
+	tokens := append([]string{code}, strings.Fields(normalize(course.Branch+" "+course.Number+" "+course.Title))...)
+	return searchEntry{code: code, tokens: tokens}
+}
+
+// Scores of a query word matching a course word.
+const (
+	scoreExact      = 10
+	scorePrefix     = 7
+	scoreTypo       = 4
+	scoreCodeExact  = 100
+	scoreCodePrefix = 50
+)
+
+// search ranks the courses matching query. Every query word has to match a word of
+// the course code or title exactly, as a prefix or with a typo, unless the whole
+// query spells out a course code.
+func (index *catalogIndex) search(query string, limit int) []models.CourseMatch {
+	words := strings.Fields(normalize(query))
+	if len(words) == 0 {
+		return []models.CourseMatch{}
+	}
+	compact := strings.Join(words, "")
+
+	matches := []models.CourseMatch{}
+	for i, entry := range index.searchEntries {
+		score := 0
+		switch {
+		case entry.code == compact:
+			score = scoreCodeExact
+		case len(compact) >= 3 && strings.HasPrefix(entry.code, compact):
+			score = scoreCodePrefix
+		default:
+			for _, word := range words {
+				best := 0
+				for _, token := range entry.tokens {
+					best = max(best, wordScore(word, token))
+				}
+				if best == 0 {
+					score = 0
+					break
+				}
+				score += best
+			}
+		}
+		if score > 0 {
+			matches = append(matches, models.CourseMatch{Score: score, Course: index.catalog.Courses[i]})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Course.Code < matches[j].Course.Code
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// wordScore scores a query word against a course word, 0 means no match.
+func wordScore(word, token string) int {
+	switch {
+	case word == token:
+		return scoreExact
+	case strings.HasPrefix(token, word):
+		return scorePrefix
+	}
+
+	// Short words match too much when typos are allowed
+	allowed := 0
+	switch n := len([]rune(word)); {
+	case n >= 8:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return 0
+	}
+	// A typo in the prefix the student typed so far also counts
+	tokenRunes := []rune(token)
+	if prefix := len([]rune(word)); len(tokenRunes) > prefix {
+		if editDistance(word, string(tokenRunes[:prefix])) <= allowed {
+			return scoreTypo
+		}
+	}
+	if editDistance(word, token) <= allowed {
+		return scoreTypo
+	}
+	return 0
+}
+
+// editDistance is the Levenshtein distance counting a swap of two neighbouring letters as one edit.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
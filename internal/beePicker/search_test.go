@@ -0,0 +1,131 @@
+package beepicker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"İşletim Sistemleri", "isletim sistemleri"},
+		{"ISLETIM SISTEMLERI", "isletim sistemleri"},
+		{"ılık ŞĞÜÖÇ şğüöç", "ilik sguoc sguoc"},
+		{"Bilgisayar Ağları", "bilgisayar aglari"},
+		{"BLG 312E", "blg 312e"},
+		{"Lineer Cebir (II)", "lineer cebir  ii "},
+		{"Mühendislik-Ekonomisi", "muhendislik ekonomisi"},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.s); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"isletim", "isletim", 0},
+		{"isletim", "isletin", 1},
+		{"isletim", "isltim", 1},
+		{"isletim", "islettim", 1},
+		{"isletim", "isltetim", 1},
+		// A swap of neighbouring letters is one edit
+		{"isletim", "isletmi", 1},
+		{"sistem", "ssitme", 2},
+		{"kitten", "sitting", 3},
+		{"ağ", "ag", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestWordScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		word, token string
+		want        int
+	}{
+		{"exact", "sistem", "sistem", scoreExact},
+		{"prefix", "sist", "sistemleri", scorePrefix},
+		{"short prefix", "s", "sistemleri", scorePrefix},
+		{"typo", "sistrm", "sistem", scoreTypo},
+		{"typo in the prefix", "sistr", "sistemleri", scoreTypo},
+		{"two typos in a long word", "bilgisyr", "bilgisayar", scoreTypo},
+		{"two typos in a short word", "sstrm", "sistem", 0},
+		{"no typos in a three letter word", "blh", "blg", 0},
+		{"longer than the token", "sistemleri", "sistem", 0},
+		{"unrelated", "fizik", "kimya", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wordScore(tt.word, tt.token); got != tt.want {
+				t.Errorf("wordScore(%q, %q) = %d, want %d", tt.word, tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	index := newCatalogIndex(&models.CourseCatalog{Courses: []models.Course{
+		{Code: "BLG 312E", Branch: "BLG", Number: "312E", Title: "İşletim Sistemleri"},
+		{Code: "BLG 317E", Branch: "BLG", Number: "317E", Title: "Veritabanı Sistemleri"},
+		{Code: "BLG 337E", Branch: "BLG", Number: "337E", Title: "Bilgisayar Ağları"},
+		{Code: "BLG 3120", Branch: "BLG", Number: "3120", Title: "Sistem Programlama"},
+		{Code: "END 312", Branch: "END", Number: "312", Title: "Sistem Simülasyonu"},
+		{Code: "ISL 201", Branch: "ISL", Number: "201", Title: "Mühendislik Ekonomisi"},
+	}})
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		// The exact code comes first, codes one typo away after it
+		{"compact code", "BLG312E", 10, []string{"BLG 312E", "BLG 3120", "BLG 317E"}},
+		{"code with a space", "blg 312e", 10, []string{"BLG 312E", "BLG 3120", "BLG 317E"}},
+		{"code without the suffix", "BLG 337", 10, []string{"BLG 337E"}},
+		// A code prefix beats every title match, ties are ordered by code
+		{"code prefix", "BLG 312", 10, []string{"BLG 3120", "BLG 312E"}},
+		{"branch prefix", "blg", 10, []string{"BLG 3120", "BLG 312E", "BLG 317E", "BLG 337E"}},
+		{"folded title", "isletim sistemleri", 10, []string{"BLG 312E"}},
+		{"turkish title", "İŞLETİM", 10, []string{"BLG 312E"}},
+		{"typo", "isletin", 10, []string{"BLG 312E"}},
+		{"folded letters", "aglari", 10, []string{"BLG 337E"}},
+		// Exact words rank above prefixes, prefixes above typos, ties by code
+		{"ranking", "sistem", 10, []string{"BLG 3120", "END 312", "BLG 312E", "BLG 317E"}},
+		{"prefixes above typos", "sistemle", 10, []string{"BLG 312E", "BLG 317E", "BLG 3120", "END 312"}},
+		{"every word has to match", "sistem fizik", 10, []string{}},
+		{"words of branch and title", "end simulasyon", 10, []string{"END 312"}},
+		{"limit", "sistem", 2, []string{"BLG 3120", "END 312"}},
+		{"punctuation only", "--", 10, []string{}},
+		{"no match", "kimya", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, match := range index.search(tt.query, tt.limit) {
+				got = append(got, match.Course.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
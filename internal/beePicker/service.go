@@ -1,290 +1,264 @@
 package beepicker
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/ITU-BeeHub/BeeHub-backend/pkg"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/session"
 	utils "github.com/ITU-BeeHub/BeeHub-backend/pkg/utils"
-
-	"github.com/go-resty/resty/v2"
 )
 
 var (
-	cache          []map[string]string // Cache verisi
-	cacheTimestamp time.Time           // Cache zaman damgası
-	cacheMutex     sync.Mutex          // Cache erişimi için mutex
+	cache          atomic.Pointer[catalogIndex] // Cache verisi, yenileme bitince tek seferde değiştirilir
+	cacheTimestamp time.Time                    // Son yenileme denemesinin zamanı
+	cacheMutex     sync.Mutex                   // cacheTimestamp ve refreshDone için mutex
+	refreshDone    chan struct{}                // Süren yenilemenin bitişi, yenileme yoksa nil
+	refreshErr     error                        // Son yenilemenin hatası
 )
 
-const raw_repo_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public"
-const most_recent_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public/most_recent.txt"
-const course_codes_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public/course_codes.json"
-const kepler_picker_url = "https://obs.itu.edu.tr/api/ders-kayit/v21"
+// catalogTTL is how long a catalog is served before it is refreshed in the background.
+const catalogTTL = 5 * time.Minute
 
-type Service struct {
-	personManager *pkg.PersonManager
-}
+// errCourseData is reported when the course catalog cannot be fetched.
+var errCourseData = apperr.ErrUpstreamUnavailable.WithMessage("cannot retrieve course information")
 
-func NewService(personManager *pkg.PersonManager) *Service {
-	return &Service{personManager: personManager}
+type Service struct {
+	kepler  kepler.KeplerClient
+	config  CatalogConfig
+	store   *diskStore
+	sources []CourseSource
+	// schedules are the user's saved schedules
+	schedules *scheduleRepository
 }
 
-func (s *Service) CourseService() ([]map[string]string, error) {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	// Cache'in geçerliliğini kontrol et
-	if time.Since(cacheTimestamp) < 5*time.Minute && cache != nil {
-		return cache, nil
+// NewService creates the BeePicker service, the course catalog is fetched and kept as configured.
+// It fails when the configured course sources are unknown.
+func NewService(keplerClient kepler.KeplerClient, config CatalogConfig) (*Service, error) {
+	if config.Workers <= 0 {
+		config.Workers = 8
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 15 * time.Second
+	}
+	store := newDiskStore(config.Dir)
+	store.githubToken = config.GitHubToken
+	sources, err := newCourseSources(config, &fetcher{config: config, store: store})
+	if err != nil {
+		return nil, err
 	}
+	return &Service{kepler: keplerClient, config: config, store: store, sources: sources, schedules: &scheduleRepository{}}, nil
+}
 
-	// Cache güncel değilse yeni veriyi çek
-	folder, err := getNewestFolder()
+// CourseService returns the course catalog and when it was fetched.
+func (s *Service) CourseService() (*models.CourseCatalog, time.Time, error) {
+	index, err := s.courseIndex()
 	if err != nil {
-		return nil, errors.New("error getting newest folder")
+		return nil, time.Time{}, err
 	}
+	return index.catalog, index.fetchedAt, nil
+}
 
-	course_codes, err := getCourseCodes()
+// CourseQueryService filters, sorts and pages the cached course catalog or the snapshot the query selects.
+func (s *Service) CourseQueryService(query CourseQuery) (*models.CoursePage, time.Time, error) {
+	index, err := s.selectIndex(query.Term, query.Snapshot)
 	if err != nil {
-		return nil, errors.New("error getting course codes")
+		return nil, time.Time{}, err
 	}
+	page, err := index.query(query)
+	return page, index.fetchedAt, err
+}
 
-	data, err := MergeCourseJsons(course_codes, folder)
+// SearchService ranks the catalog courses matching a free text query.
+func (s *Service) SearchService(query string, limit int) ([]models.CourseMatch, time.Time, error) {
+	index, err := s.courseIndex()
 	if err != nil {
-		return nil, errors.New("error getting course data")
+		return nil, time.Time{}, err
 	}
+	return index.search(query, limit), index.fetchedAt, nil
+}
 
-	var convertedData []map[string]string
-	for _, item := range data {
-		convertedItem := make(map[string]string)
-		for key, value := range item {
-			convertedItem[key] = fmt.Sprintf("%v", value)
+// courseIndex returns the cached catalog without waiting for the course sources. A catalog older
+// than catalogTTL is still served while a single background refresh replaces it.
+// Only the very first request, when there is no snapshot on disk either, waits for the refresh.
+func (s *Service) courseIndex() (*catalogIndex, error) {
+	if index := cache.Load(); index != nil {
+		cacheMutex.Lock()
+		stale := time.Since(cacheTimestamp) >= catalogTTL
+		cacheMutex.Unlock()
+		if stale {
+			s.startRefresh()
 		}
-		convertedData = append(convertedData, convertedItem)
+		return index, nil
 	}
 
-	// Veriyi cache'e kaydet ve zaman damgasını güncelle
-	cache = convertedData
-	cacheTimestamp = time.Now()
+	cacheMutex.Lock()
+	if cache.Load() == nil && refreshDone == nil {
+		snap, err := s.store.loadSnapshot()
+		if err != nil {
+			log.Println("Error loading course catalog snapshot:", err)
+		}
+		if snap != nil {
+			cache.Store(snap.index())
+		}
+	}
+	cacheMutex.Unlock()
+	if index := cache.Load(); index != nil {
+		s.startRefresh()
+		return index, nil
+	}
 
-	return convertedData, nil
+	<-s.startRefresh()
+	if index := cache.Load(); index != nil {
+		return index, nil
+	}
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	return nil, refreshErr
 }
 
-func MergeCourseJsons(course_codes []string, newest_folder string) ([]map[string]interface{}, error) {
-    base_url := raw_repo_URL + "/" + newest_folder + "/"
-
-    var allCourses []map[string]interface{}
-
-    courseDataChan := make(chan []map[string]interface{})
-    var wg sync.WaitGroup
-
-    for _, course_code := range course_codes {
-        wg.Add(1)
-        go func(code string) {
-            defer wg.Done()
-            resp, err := http.Get(base_url + code + ".json")
-            if resp.StatusCode != http.StatusOK {
-                log.Printf("Failed to retrieve JSON for course code %s: %s", code, resp.Status)
-                return
-            }
-            if err != nil {
-                log.Println("Error getting course json:", err)
-                return
-            }
-            defer resp.Body.Close()
-
-            body, err := io.ReadAll(resp.Body)
-            if err != nil {
-                log.Println("Error reading course json:", err)
-                return
-            }
-
-            // Create a struct to capture the new response format
-            var result struct {
-                DersProgramList []map[string]interface{} `json:"dersProgramList"`
-            }
-
-            // Unmarshal into the new struct to access the list
-            err = json.Unmarshal(body, &result)
-            if err != nil {
-                log.Println("Error unmarshaling course json:", err)
-                log.Println("Faulty JSON:", string(body))
-                return
-            }
-
-            // Send the array from the "dersProgramList" to the channel
-            courseDataChan <- result.DersProgramList
-        }(course_code)
-    }
-
-    go func() {
-        wg.Wait()
-        close(courseDataChan)
-    }()
+// startRefresh starts a background refresh unless one is running already, the
+// returned channel is closed when it ends. A failed refresh keeps the current catalog
+// and is only logged and counted, it is retried after catalogTTL.
+func (s *Service) startRefresh() <-chan struct{} {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	if refreshDone != nil {
+		return refreshDone
+	}
+	if index := cache.Load(); index != nil && time.Since(cacheTimestamp) < catalogTTL {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
 
-    // Collect all the courses from the channel
-    for courses := range courseDataChan {
-        allCourses = append(allCourses, courses...)
-    }
+	done := make(chan struct{})
+	refreshDone = done
+	cacheTimestamp = time.Now()
+	go func() {
+		started := time.Now()
+		index, err := s.refreshCatalog()
+		catalogMetrics.record(time.Since(started), err)
+		if err != nil {
+			log.Println("Course catalog refresh failed:", err)
+		} else {
+			publishChanges(cache.Swap(index), index)
+		}
 
-    return allCourses, nil
+		cacheMutex.Lock()
+		refreshErr = err
+		refreshDone = nil
+		cacheMutex.Unlock()
+		close(done)
+	}()
+	return done
 }
 
+// refreshCatalog reads the course sources in order and saves the first usable
+// offering as the new snapshot. An offering missing more than MaxFailedBranches
+// branches does not replace the catalog being served, but it is still better than
+// no catalog at all.
+func (s *Service) refreshCatalog() (*catalogIndex, error) {
+	var errs []error
+	for _, source := range s.sources {
+		data, err := s.fetchSource(source)
+		if err != nil {
+			log.Printf("Course source %s failed: %v", source.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
 
-func getCourseCodes() ([]string, error) {
-	resp, err := http.Get(course_codes_URL)
-	if err != nil {
-		return []string{}, err
-	}
-	defer resp.Body.Close()
-
-	var course_codes_response []map[string]interface{}
-	course_codes_bytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return []string{}, err
-	}
-	err = json.Unmarshal(course_codes_bytes, &course_codes_response)
-	if err != nil {
-		return []string{}, err
-	}
-
-	var course_codes []string
-	for _, course := range course_codes_response {
-		if code, ok := course["dersBransKodu"].(string); ok {
-			course_codes = append(course_codes, code)
+		snap := &snapshot{FetchedAt: time.Now(), Source: source.Name(), Version: data.Version, Catalog: data.Catalog}
+		if err := s.store.saveSnapshot(snap); err != nil {
+			log.Println("Error saving course catalog snapshot:", err)
 		}
-	}
 
-	return course_codes, nil
+		return snap.index(), nil
+	}
+	return nil, errCourseData.Wrap(errors.Join(errs...))
 }
 
-func getNewestFolder() (string, error) {
-	// Gets the most recent folder name
-	resp, err := http.Get(most_recent_URL)
+// fetchSource reads a course source and decides whether its offering is usable.
+func (s *Service) fetchSource(source CourseSource) (*CourseData, error) {
+	data, err := source.Fetch(context.Background())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	most_recent_file_name, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	catalogMetrics.recordReport(data.Report)
+	if len(data.Report.Failed) == data.Report.Branches {
+		return nil, fmt.Errorf("no branch could be read")
 	}
-
-	return string(most_recent_file_name), nil
+	if len(data.Report.Failed) > s.config.MaxFailedBranches && cache.Load() != nil {
+		return nil, fmt.Errorf("incomplete course data, missing %s", strings.Join(data.Report.FailedBranches(), ", "))
+	}
+	return data, nil
 }
 
-func (s *Service) PickService(courseCodes []string) (map[string]map[string]interface{}, error) {
-	client := resty.New()
-
-	responses, err := sendCourseRequests(client, courseCodes, s.personManager.GetToken())
+func (s *Service) PickService(sess *session.Session, courseCodes []string) (map[string]kepler.CRNResult, error) {
+	if err := s.checkConflicts(courseCodes); err != nil {
+		return nil, err
+	}
+	responses, err := s.sendCourseRequests(courseCodes, sess.TokenKeeper())
 	if err != nil {
-		return nil, fmt.Errorf("error sending course requests: %v", err)
+		return nil, err
 	}
-	return mergePickResponses(responses)
+	return mergePickResponses(responses), nil
 }
 
-func sendCourseRequests(client *resty.Client, courses []string, token string) ([]*resty.Response, error) {
-	var responses []*resty.Response
-	var errors []error
-	headers := map[string]string{
-		"accept":        "application/json, text/plain, */*",
-		"authorization": "Bearer  " + token,
-		"origin":        "https://obs.itu.edu.tr",
-		"referer":       "https://obs.itu.edu.tr/ogrenci/DersKayitIslemleri/DersKayit",
-	}
-	payload := map[string]interface{}{
-		"ECRN": courses,    // Example CRNs to be added
-		"SCRN": []string{}, // Example CRNs to be deleted
-	}
+// sendCourseRequests posts the CRNs to Kepler five times.
+// A request rejected with 401 is retried once with a renewed token.
+func (s *Service) sendCourseRequests(courses []string, keeper *kepler.TokenKeeper) ([]*kepler.RegisterResponse, error) {
+	var responses []*kepler.RegisterResponse
+	var errs []error
 	for i := 0; i < 5; i++ {
-		resp, err := client.R().
-			SetHeaders(headers).
-			SetBody(payload).
-			Post(kepler_picker_url)
+		var response *kepler.RegisterResponse
+		err := keeper.Do(func(token string) error {
+			var err error
+			response, err = s.kepler.Register(token, courses, nil)
+			return err
+		})
 
 		if err != nil {
-			errors = append(errors, err)
+			errs = append(errs, err)
 			continue
 		}
-		responses = append(responses, resp)
-		fmt.Println()
-		fmt.Println(responses)
+		responses = append(responses, response)
 		// Saniyede bir istek göndermek için bekleme
 		time.Sleep(3100 * time.Millisecond)
 	}
 
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("errors occurred while sending course requests: %v", errors)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	return responses, nil
 }
 
-func mergePickResponses(responses []*resty.Response) (map[string]map[string]interface{}, error) {
-	pickResults := make(map[string]map[string]interface{})
+// mergePickResponses keeps one result per CRN, a success wins over earlier failures.
+func mergePickResponses(responses []*kepler.RegisterResponse) map[string]kepler.CRNResult {
+	pickResults := make(map[string]kepler.CRNResult)
 	errorCodes := utils.GetErrorCodes()
 
-	for _, resp := range responses {
-		if resp.StatusCode() != http.StatusOK {
-			return nil, fmt.Errorf("non-200 status code received: %d", resp.StatusCode())
-		}
-
-		var result struct {
-			EcrnResultList []map[string]interface{} `json:"ecrnResultList"`
-			ScrnResultList []map[string]interface{} `json:"scrnResultList"`
-		}
-
-		if err := json.Unmarshal(resp.Body(), &result); err != nil {
-			return nil, fmt.Errorf("error unmarshaling response: %v", err)
-		}
-
-		for _, ecrnResult := range result.EcrnResultList {
-			crn := ecrnResult["crn"].(string)
-			statusCode := int(ecrnResult["statusCode"].(float64))
-			resultCode := ecrnResult["resultCode"].(string)
-
-			// Populate the resultData field using GetErrorCodes
-			ecrnResult["resultData"] = fmt.Sprintf(errorCodes[resultCode], crn)
-
-			// Check if the CRN is already in the map
-			if existingResult, exists := pickResults[crn]; exists {
-				// Keep the one with statusCode = 0 (success)
-				if existingStatusCode := int(existingResult["statusCode"].(float64)); existingStatusCode != 0 && statusCode == 0 {
-					pickResults[crn] = ecrnResult
-				}
-			} else {
-				// Add the CRN to the map
-				pickResults[crn] = ecrnResult
-			}
-		}
-
-		for _, scrnResult := range result.ScrnResultList {
-			crn := scrnResult["crn"].(string)
-			statusCode := int(scrnResult["statusCode"].(float64))
-			resultCode := scrnResult["resultCode"].(string)
-
+	for _, response := range responses {
+		for _, crnResult := range append(response.ECRNResultList, response.SCRNResultList...) {
 			// Populate the resultData field using GetErrorCodes
-			scrnResult["resultData"] = fmt.Sprintf(errorCodes[resultCode], crn)
+			resultData := fmt.Sprintf(errorCodes[crnResult.ResultCode], crnResult.CRN)
+			crnResult.ResultData = &resultData
 
-			// Check if the CRN is already in the map
-			if existingResult, exists := pickResults[crn]; exists {
-				// Keep the one with statusCode = 0 (success)
-				if existingStatusCode := int(existingResult["statusCode"].(float64)); existingStatusCode != 0 && statusCode == 0 {
-					pickResults[crn] = scrnResult
-				}
-			} else {
-				// Add the CRN to the map
-				pickResults[crn] = scrnResult
+			// Keep the one with statusCode = 0 (success)
+			if existingResult, exists := pickResults[crnResult.CRN]; !exists || (existingResult.StatusCode != 0 && crnResult.StatusCode == 0) {
+				pickResults[crnResult.CRN] = crnResult
 			}
 		}
 	}
 
-	return pickResults, nil
+	return pickResults
 }
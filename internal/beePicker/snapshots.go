@@ -0,0 +1,226 @@
+package beepicker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// SnapshotSource is a CourseSource that keeps the offerings it published before,
+// e.g. one scraper run folder per term.
+type SnapshotSource interface {
+	CourseSource
+	// Snapshots lists the names of the offerings, newer offerings have greater names
+	Snapshots(ctx context.Context) ([]string, error)
+	// FetchSnapshot reads the offering with the given name
+	FetchSnapshot(ctx context.Context, name string) (*CourseData, error)
+}
+
+// maxSnapshots is the number of older snapshots kept in memory besides the current catalog.
+const maxSnapshots = 8
+
+// snapshotListTimeout bounds listing the snapshots, retries included.
+const snapshotListTimeout = 30 * time.Second
+
+var (
+	errUnknownSnapshot = apperr.ErrNotFound.WithMessage("unknown term or snapshot")
+	errNoSnapshots     = apperr.ErrUnsupported.WithMessage("the course source does not keep older snapshots")
+)
+
+// snapshotCache keeps the catalogs of older snapshots. They do not change any more,
+// so they are only evicted, never refreshed.
+type snapshotCache struct {
+	mu       sync.Mutex
+	indexes  map[string]*catalogIndex
+	used     map[string]time.Time
+	loading  map[string]chan struct{}
+	list     []string
+	listedAt time.Time
+	// listing is closed when the running listing ends, nil if none is running
+	listing chan struct{}
+}
+
+var snapshots = &snapshotCache{
+	indexes: make(map[string]*catalogIndex),
+	used:    make(map[string]time.Time),
+	loading: make(map[string]chan struct{}),
+}
+
+// names returns the names of the snapshots whose catalog is cached.
+func (c *snapshotCache) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.indexes))
+	for name := range c.indexes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// snapshotSource returns the first configured source that keeps snapshots.
+func (s *Service) snapshotSource() SnapshotSource {
+	for _, source := range s.sources {
+		if source, ok := source.(SnapshotSource); ok {
+			return source
+		}
+	}
+	return nil
+}
+
+// SnapshotsService lists the snapshots of the course source, newest first.
+func (s *Service) SnapshotsService() ([]models.CatalogSnapshot, error) {
+	source := s.snapshotSource()
+	if source == nil {
+		return nil, errNoSnapshots
+	}
+	names, err := s.snapshotNames(source)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := s.courseIndex()
+	list := make([]models.CatalogSnapshot, len(names))
+	for i, name := range names {
+		list[i] = models.CatalogSnapshot{
+			Name:    name,
+			Source:  source.Name(),
+			Current: current != nil && current.source == source.Name() && current.version == name,
+		}
+	}
+	return list, nil
+}
+
+// snapshotNames lists the snapshots, the list is cached as long as the catalog.
+// Only one listing runs at a time, concurrent callers wait for its result.
+func (s *Service) snapshotNames(source SnapshotSource) ([]string, error) {
+	waited := false
+	for {
+		snapshots.mu.Lock()
+		if snapshots.list != nil && (waited || time.Since(snapshots.listedAt) < catalogTTL) {
+			list := snapshots.list
+			snapshots.mu.Unlock()
+			return list, nil
+		}
+		listing := snapshots.listing
+		if listing == nil {
+			break
+		}
+		snapshots.mu.Unlock()
+		<-listing
+		// A failed listing keeps the older list, without one the next round tries again
+		waited = true
+	}
+	done := make(chan struct{})
+	snapshots.listing = done
+	snapshots.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotListTimeout)
+	names, err := source.Snapshots(ctx)
+	cancel()
+
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+	snapshots.listing = nil
+	close(done)
+	if err != nil {
+		if snapshots.list != nil {
+			return snapshots.list, nil
+		}
+		return nil, errCourseData.Wrap(err)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	snapshots.list, snapshots.listedAt = names, time.Now()
+	return names, nil
+}
+
+// selectIndex returns the catalog of a snapshot, picked by its name or as the newest
+// snapshot whose name starts with term, preferring the current catalog. Without
+// either it is the current catalog.
+func (s *Service) selectIndex(term, snapshot string) (*catalogIndex, error) {
+	if term == "" && snapshot == "" {
+		return s.courseIndex()
+	}
+	source := s.snapshotSource()
+	if source == nil {
+		return nil, errNoSnapshots
+	}
+	names, err := s.snapshotNames(source)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.courseIndex()
+	if err == nil && current.source == source.Name() &&
+		(current.version == snapshot || snapshot == "" && strings.HasPrefix(normalize(current.version), normalize(term))) {
+		return current, nil
+	}
+
+	name := ""
+	for _, candidate := range names {
+		if snapshot != "" && candidate == snapshot ||
+			snapshot == "" && strings.HasPrefix(normalize(candidate), normalize(term)) {
+			name = candidate
+			break
+		}
+	}
+	if name == "" {
+		return nil, errUnknownSnapshot
+	}
+	return s.snapshotIndex(source, name)
+}
+
+// snapshotIndex returns the catalog of an older snapshot, fetching it once.
+func (s *Service) snapshotIndex(source SnapshotSource, name string) (*catalogIndex, error) {
+	for {
+		snapshots.mu.Lock()
+		if index, ok := snapshots.indexes[name]; ok {
+			snapshots.used[name] = time.Now()
+			snapshots.mu.Unlock()
+			return index, nil
+		}
+		loading, ok := snapshots.loading[name]
+		if !ok {
+			break
+		}
+		snapshots.mu.Unlock()
+		<-loading
+		// The load may have failed, then the next round tries again
+	}
+	done := make(chan struct{})
+	snapshots.loading[name] = done
+	snapshots.mu.Unlock()
+
+	data, err := source.FetchSnapshot(context.Background(), name)
+
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+	delete(snapshots.loading, name)
+	close(done)
+	if err != nil {
+		return nil, errCourseData.Wrap(err)
+	}
+	if len(data.Report.Failed) == data.Report.Branches {
+		return nil, errCourseData.WithMessage("the snapshot has no course data")
+	}
+
+	index := newCatalogIndex(data.Catalog)
+	index.fetchedAt, index.source, index.version = time.Now(), source.Name(), name
+	snapshots.indexes[name] = index
+	snapshots.used[name] = time.Now()
+	for len(snapshots.indexes) > maxSnapshots {
+		oldest := ""
+		for key, used := range snapshots.used {
+			if oldest == "" || used.Before(snapshots.used[oldest]) {
+				oldest = key
+			}
+		}
+		delete(snapshots.indexes, oldest)
+		delete(snapshots.used, oldest)
+	}
+	return index, nil
+}
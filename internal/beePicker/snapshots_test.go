@@ -0,0 +1,119 @@
+package beepicker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowSnapshots lists its snapshots once release is closed.
+type slowSnapshots struct {
+	release chan struct{}
+	calls   atomic.Int32
+	err     error
+}
+
+func (f *slowSnapshots) Name() string { return "slow" }
+
+func (f *slowSnapshots) Fetch(context.Context) (*CourseData, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *slowSnapshots) FetchSnapshot(context.Context, string) (*CourseData, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *slowSnapshots) Snapshots(ctx context.Context) ([]string, error) {
+	f.calls.Add(1)
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("listing without a deadline")
+	}
+	<-f.release
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []string{"2024-09-01", "2025-02-01"}, nil
+}
+
+func resetSnapshots(t *testing.T) {
+	t.Cleanup(func() {
+		snapshots.mu.Lock()
+		snapshots.list, snapshots.listedAt = nil, time.Time{}
+		snapshots.mu.Unlock()
+	})
+}
+
+func TestSnapshotNamesSingleFlight(t *testing.T) {
+	resetSnapshots(t)
+	source := &slowSnapshots{release: make(chan struct{})}
+	s := &Service{}
+
+	var wg sync.WaitGroup
+	results := make([][]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			names, err := s.snapshotNames(source)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = names
+		}()
+	}
+
+	// The cache stays usable while the listing is running
+	deadline := time.Now().Add(time.Second)
+	for source.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	locked := make(chan struct{})
+	go func() {
+		snapshots.names()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("the snapshot cache is locked during the listing")
+	}
+
+	close(source.release)
+	wg.Wait()
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("listed %d times, want 1", calls)
+	}
+	want := []string{"2025-02-01", "2024-09-01"}
+	for _, names := range results {
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("snapshotNames() = %q, want %q", names, want)
+		}
+	}
+}
+
+func TestSnapshotNamesFailure(t *testing.T) {
+	resetSnapshots(t)
+	source := &slowSnapshots{release: make(chan struct{}), err: errors.New("rate limited")}
+	close(source.release)
+	s := &Service{}
+
+	if _, err := s.snapshotNames(source); !errors.Is(err, errCourseData) {
+		t.Errorf("snapshotNames() error = %v, want errCourseData", err)
+	}
+
+	// A stale list is better than none when the listing fails
+	snapshots.mu.Lock()
+	snapshots.list, snapshots.listedAt = []string{"2024-09-01"}, time.Now().Add(-catalogTTL)
+	snapshots.mu.Unlock()
+	names, err := s.snapshotNames(source)
+	if err != nil || !reflect.DeepEqual(names, []string{"2024-09-01"}) {
+		t.Errorf("snapshotNames() = %q, %v, want the stale list", names, err)
+	}
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("listed %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,51 @@
+package beepicker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// CourseSource provides the course offering BeePicker serves.
+type CourseSource interface {
+	// Name is the name the source is configured with
+	Name() string
+	// Fetch returns the newest course offering of the source. Branches the source
+	// could not read are listed in the report instead of failing the whole fetch.
+	Fetch(ctx context.Context) (*CourseData, error)
+}
+
+// CourseData is a course offering read from a CourseSource.
+type CourseData struct {
+	// Version tells offerings of the same source apart, e.g. the scraper run folder
+	Version string
+	Catalog *models.CourseCatalog
+	Report  *FetchReport
+}
+
+// newCourseSources creates the configured sources in order, GitHub when none is configured.
+func newCourseSources(config CatalogConfig, f *fetcher) ([]CourseSource, error) {
+	names := config.Sources
+	if len(names) == 0 {
+		names = []string{"github"}
+	}
+
+	var sources []CourseSource
+	for _, name := range names {
+		switch name {
+		case "github":
+			sources = append(sources, &githubSource{fetcher: f})
+		case "sis":
+			sources = append(sources, &sisSource{fetcher: f})
+		case "local":
+			if config.LocalDir == "" {
+				return nil, fmt.Errorf("the local course source needs a directory")
+			}
+			sources = append(sources, &localSource{dir: config.LocalDir})
+		default:
+			return nil, fmt.Errorf("unknown course source %q", name)
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,137 @@
+package beepicker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const raw_repo_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public"
+const most_recent_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public/most_recent.txt"
+const course_codes_URL = "https://raw.githubusercontent.com/ITU-BeeHub/BeeHub-courseScraper/main/public/course_codes.json"
+const github_API_URL = "https://api.github.com/"
+const contents_URL = github_API_URL + "repos/ITU-BeeHub/BeeHub-courseScraper/contents/public"
+
+// githubSource reads the files BeeHub-courseScraper publishes on GitHub. Every scraper
+// run is a folder holding one JSON file per branch, most_recent.txt names the newest.
+type githubSource struct {
+	*fetcher
+}
+
+func (g *githubSource) Name() string { return "github" }
+
+func (g *githubSource) Fetch(ctx context.Context) (*CourseData, error) {
+	folder, err := g.getNewestFolder(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting newest folder: %w", err)
+	}
+
+	data, err := g.FetchSnapshot(ctx, folder)
+	if err == nil && data.Report.Complete() {
+		g.store.prune(append(snapshots.names(), folder)...)
+	}
+	return data, err
+}
+
+// Snapshots lists the scraper run folders through the GitHub contents API. The API is
+// rate limited, so while it fails the list saved by the last successful call is used.
+func (g *githubSource) Snapshots(ctx context.Context) ([]string, error) {
+	body, err := g.get(ctx, contents_URL)
+	if err != nil {
+		if saved, loadErr := g.store.loadSnapshotList(); loadErr == nil && saved != nil {
+			log.Printf("Cannot list the scraper runs, using the saved list: %v", err)
+			return saved, nil
+		}
+		return nil, err
+	}
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("decoding folder list: %w", err)
+	}
+
+	folders := []string{}
+	for _, entry := range entries {
+		if entry.Type == "dir" {
+			folders = append(folders, entry.Name)
+		}
+	}
+	if err := g.store.saveSnapshotList(folders); err != nil {
+		log.Printf("Cannot save the scraper run list: %v", err)
+	}
+	return folders, nil
+}
+
+// FetchSnapshot downloads the branch files of a scraper run folder.
+func (g *githubSource) FetchSnapshot(ctx context.Context, folder string) (*CourseData, error) {
+	if folder == "" || strings.ContainsAny(folder, "/\\?#") || strings.HasPrefix(folder, ".") {
+		return nil, fmt.Errorf("invalid folder name %q", folder)
+	}
+
+	course_codes, err := g.getCourseCodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting course codes: %w", err)
+	}
+
+	data, report := g.MergeCourseJsons(ctx, course_codes, folder)
+	return &CourseData{Version: folder, Catalog: toCatalog(data), Report: report}, nil
+}
+
+// MergeCourseJsons downloads the branch files of a scraper run.
+func (g *githubSource) MergeCourseJsons(ctx context.Context, course_codes []string, newest_folder string) ([]scraperSection, *FetchReport) {
+	base_url := raw_repo_URL + "/" + newest_folder + "/"
+	return g.branches(ctx, course_codes, func(ctx context.Context, code string) ([]scraperSection, error) {
+		body, err := g.get(ctx, base_url+code+".json")
+		if err != nil {
+			return nil, err
+		}
+		return decodeBranch(body)
+	})
+}
+
+// decodeBranch decodes the course file of a branch.
+func decodeBranch(body []byte) ([]scraperSection, error) {
+	var result struct {
+		DersProgramList []scraperSection `json:"dersProgramList"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("decoding course json: %w", err)
+	}
+	return result.DersProgramList, nil
+}
+
+func (g *githubSource) getCourseCodes(ctx context.Context) ([]string, error) {
+	course_codes_bytes, err := g.get(ctx, course_codes_URL)
+	if err != nil {
+		return []string{}, err
+	}
+
+	var course_codes_response []map[string]interface{}
+	err = json.Unmarshal(course_codes_bytes, &course_codes_response)
+	if err != nil {
+		return []string{}, err
+	}
+
+	var course_codes []string
+	for _, course := range course_codes_response {
+		if code, ok := course["dersBransKodu"].(string); ok {
+			course_codes = append(course_codes, code)
+		}
+	}
+
+	return course_codes, nil
+}
+
+func (g *githubSource) getNewestFolder(ctx context.Context) (string, error) {
+	// Gets the most recent folder name
+	most_recent_file_name, err := g.get(ctx, most_recent_URL)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(most_recent_file_name)), nil
+}
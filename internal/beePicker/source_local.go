@@ -0,0 +1,102 @@
+package beepicker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// localSource reads a local copy of the scraper repository's public folder, so
+// BeePicker can run offline from a mirror. The branch files are read from the folder
+// most_recent.txt names or, without most_recent.txt, from dir itself.
+type localSource struct {
+	dir string
+}
+
+func (l *localSource) Name() string { return "local" }
+
+func (l *localSource) Fetch(ctx context.Context) (*CourseData, error) {
+	data, err := os.ReadFile(filepath.Join(l.dir, "most_recent.txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return l.read(ctx, l.dir, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.FetchSnapshot(ctx, strings.TrimSpace(string(data)))
+}
+
+// Snapshots lists the run folders of the mirror.
+func (l *localSource) Snapshots(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	folders := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			folders = append(folders, entry.Name())
+		}
+	}
+	return folders, nil
+}
+
+func (l *localSource) FetchSnapshot(ctx context.Context, name string) (*CourseData, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid folder name %q", name)
+	}
+	return l.read(ctx, filepath.Join(l.dir, name), name)
+}
+
+// read reads the branch files of folder, a folder without a version is versioned by its latest change.
+func (l *localSource) read(ctx context.Context, folder, version string) (*CourseData, error) {
+	files, err := filepath.Glob(filepath.Join(folder, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	report := &FetchReport{Failed: []BranchFailure{}}
+	var sections []scraperSection
+	for _, file := range files {
+		branch := strings.TrimSuffix(filepath.Base(file), ".json")
+		if branch == "course_codes" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		report.Branches++
+		body, err := os.ReadFile(file)
+		if err == nil {
+			var branchSections []scraperSection
+			if branchSections, err = decodeBranch(body); err == nil {
+				sections = append(sections, branchSections...)
+				continue
+			}
+		}
+		report.Failed = append(report.Failed, BranchFailure{Branch: branch, Error: err.Error()})
+	}
+	if version == "" {
+		version = newestModTime(files)
+	}
+	return &CourseData{Version: version, Catalog: toCatalog(sections), Report: report}, nil
+}
+
+// newestModTime versions a folder without most_recent.txt by its latest change.
+func newestModTime(files []string) string {
+	var newest string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			if modified := info.ModTime().UTC().Format("2006-01-02T15:04:05Z"); modified > newest {
+				newest = modified
+			}
+		}
+	}
+	return newest
+}
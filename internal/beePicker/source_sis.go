@@ -0,0 +1,192 @@
+package beepicker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const sis_URL = "https://www.sis.itu.edu.tr/TR/ogrenci/ders-programi/ders-programi.php?seviye=LS"
+
+// sisSource scrapes the course schedule pages of sis.itu.edu.tr, the pages the
+// course scraper itself reads. It has no versions, every fetch is the live offering.
+type sisSource struct {
+	*fetcher
+}
+
+func (s *sisSource) Name() string { return "sis" }
+
+func (s *sisSource) Fetch(ctx context.Context) (*CourseData, error) {
+	fetchedAt := time.Now()
+	codes, err := s.branchCodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting branch codes: %w", err)
+	}
+
+	sections, report := s.branches(ctx, codes, func(ctx context.Context, code string) ([]scraperSection, error) {
+		body, err := s.get(ctx, sis_URL+"&derskodu="+code)
+		if err != nil {
+			return nil, err
+		}
+		return parseSISSchedule(body)
+	})
+	return &CourseData{Version: fetchedAt.UTC().Format(time.RFC3339), Catalog: toCatalog(sections), Report: report}, nil
+}
+
+// branchCodes reads the branch codes offered in the branch select box.
+func (s *sisSource) branchCodes(ctx context.Context) ([]string, error) {
+	body, err := s.get(ctx, sis_URL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	doc.Find("option").Each(func(_ int, option *goquery.Selection) {
+		if value, ok := option.Attr("value"); ok && len(value) == 3 {
+			codes = append(codes, value)
+		}
+	})
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no branch codes on the schedule page")
+	}
+	return codes, nil
+}
+
+// sisColumns maps the normalized Turkish and English column titles of the schedule table.
+var sisColumns = map[string]string{
+	"crn":                       "crn",
+	"course code":               "code",
+	"ders kodu":                 "code",
+	"course title":              "title",
+	"ders adi":                  "title",
+	"teaching method":           "method",
+	"ogretim yontemi":           "method",
+	"instructor":                "instructor",
+	"egitmen":                   "instructor",
+	"ogretim uyesi":             "instructor",
+	"building":                  "building",
+	"bina":                      "building",
+	"day":                       "day",
+	"gun":                       "day",
+	"time":                      "time",
+	"saat":                      "time",
+	"room":                      "room",
+	"derslik":                   "room",
+	"capacity":                  "capacity",
+	"kontenjan":                 "capacity",
+	"enrolled":                  "enrolled",
+	"yazilan":                   "enrolled",
+	"reservation":               "reservation",
+	"rezervasyon":               "reservation",
+	"major restriction":         "programs",
+	"dersi alabilen programlar": "programs",
+	"prerequisites":             "prerequisites",
+	"onsartlar":                 "prerequisites",
+	"class restriction":         "class",
+	"sinif onsarti":             "class",
+}
+
+// parseSISSchedule reads the sections of a branch schedule page. Sections meeting
+// more than once list one day, time, building and room per line.
+func parseSISSchedule(body []byte) ([]scraperSection, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []scraperSection{}
+	var columns []string
+	doc.Find("div.table-responsive tr").Each(func(_ int, row *goquery.Selection) {
+		cells := row.Find("td, th")
+		if columns == nil {
+			if strings.EqualFold(strings.TrimSpace(cells.First().Text()), "CRN") {
+				cells.Each(func(_ int, cell *goquery.Selection) {
+					columns = append(columns, sisColumns[strings.Join(strings.Fields(normalize(cell.Text())), " ")])
+				})
+			}
+			return
+		}
+
+		var section scraperSection
+		cells.Each(func(i int, cell *goquery.Selection) {
+			if i >= len(columns) {
+				return
+			}
+			cell.Find("br").ReplaceWithHtml("\n")
+			lines := flexList{}
+			for _, line := range strings.Split(cell.Text(), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			text := flexString(strings.Join(lines, ", "))
+
+			switch columns[i] {
+			case "crn":
+				section.CRN = text
+			case "code":
+				section.CourseCode = text
+			case "title":
+				section.Title = text
+			case "method":
+				section.TeachingMethod = text
+			case "instructor":
+				section.Instructors = lines
+			case "building":
+				section.Buildings = lines
+			case "day":
+				for _, day := range lines {
+					section.DaysEN = append(section.DaysEN, englishDay(day))
+				}
+			case "time":
+				// "0830/1029"
+				for _, line := range lines {
+					start, end, _ := strings.Cut(line, "/")
+					section.Starts = append(section.Starts, sisClock(start))
+					section.Ends = append(section.Ends, sisClock(end))
+				}
+			case "room":
+				section.Rooms = lines
+			case "capacity":
+				capacity, _ := strconv.Atoi(string(text))
+				section.Capacity = flexNumber(capacity)
+			case "enrolled":
+				enrolled, _ := strconv.Atoi(string(text))
+				section.Enrolled = flexNumber(enrolled)
+			case "reservation":
+				section.Reservation = text
+			case "programs":
+				section.Programs = text
+			case "prerequisites":
+				section.Prerequisites = text
+			case "class":
+				section.ClassRestriction = text
+			}
+		})
+		if section.CRN != "" {
+			sections = append(sections, section)
+		}
+	})
+	if columns == nil {
+		return nil, fmt.Errorf("no schedule table on the page")
+	}
+	return sections, nil
+}
+
+// sisClock turns "0830" into "08:30".
+func sisClock(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) != 4 {
+		return ""
+	}
+	return value[:2] + ":" + value[2:]
+}
@@ -0,0 +1,232 @@
+package beepicker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// diskStore keeps the files downloaded from the course scraper repository and the
+// last good catalog snapshot on disk, so that restarts and source outages do not
+// leave BeePicker without courses. An empty dir keeps nothing on disk.
+type diskStore struct {
+	dir    string
+	client *http.Client
+	// githubToken is sent with the requests to the GitHub API only
+	githubToken string
+}
+
+// snapshot is a catalog built by a refresh.
+type snapshot struct {
+	// FetchedAt is when the catalog was last read from its source
+	FetchedAt time.Time `json:"fetched_at"`
+	// Source and Version tell which course offering the catalog was built from
+	Source  string                `json:"source"`
+	Version string                `json:"version"`
+	Catalog *models.CourseCatalog `json:"catalog"`
+}
+
+func (snap *snapshot) index() *catalogIndex {
+	index := newCatalogIndex(snap.Catalog)
+	index.fetchedAt, index.source, index.version = snap.FetchedAt, snap.Source, snap.Version
+	return index
+}
+
+// fileMeta holds the validators of a downloaded file.
+type fileMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+const (
+	snapshotFile     = "catalog.json"
+	snapshotListFile = "snapshots.json"
+)
+
+func newDiskStore(dir string) *diskStore {
+	return &diskStore{dir: dir, client: &http.Client{}}
+}
+
+// get downloads url. A copy on disk is revalidated with If-None-Match and
+// If-Modified-Since and reused when the server answers 304 Not Modified.
+func (d *diskStore) get(ctx context.Context, url string) ([]byte, error) {
+	path := d.path(url)
+	var meta fileMeta
+	var cached []byte
+	if path != "" {
+		if data, err := os.ReadFile(path + ".meta"); err == nil && json.Unmarshal(data, &meta) == nil {
+			cached, _ = os.ReadFile(path)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.githubToken != "" && strings.HasPrefix(url, github_API_URL) {
+		req.Header.Set("Authorization", "Bearer "+d.githubToken)
+	}
+	if cached != nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, &statusError{url: url, status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		meta = fileMeta{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		if err := d.writeFile(path, body); err == nil {
+			if data, err := json.Marshal(meta); err == nil {
+				d.writeFile(path+".meta", data)
+			}
+		}
+	}
+	return body, nil
+}
+
+// path maps a scraper repository URL to its place on disk, e.g. "<dir>/2024-09-01/BLG.json".
+func (d *diskStore) path(url string) string {
+	if d.dir == "" || !strings.HasPrefix(url, raw_repo_URL+"/") {
+		return ""
+	}
+	name := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(url, raw_repo_URL+"/")))
+	if name == "." || strings.HasPrefix(name, "..") || filepath.IsAbs(name) {
+		return ""
+	}
+	return filepath.Join(d.dir, "files", name)
+}
+
+// prune removes the folders of older scraper runs except the ones to keep. Folders of
+// snapshots downloaded for term and snapshot queries are kept while their catalog
+// is cached, afterwards they are downloaded again when needed.
+func (d *diskStore) prune(keep ...string) {
+	if d.dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(filepath.Join(d.dir, "files"))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !slices.Contains(keep, entry.Name()) {
+			os.RemoveAll(filepath.Join(d.dir, "files", entry.Name()))
+		}
+	}
+}
+
+// loadSnapshot reads the last saved snapshot, it returns nil if there is none.
+func (d *diskStore) loadSnapshot() (*snapshot, error) {
+	if d.dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(d.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	// Snapshots of an older schema are rebuilt from the downloaded files instead
+	if snap.Catalog == nil || snap.Catalog.SchemaVersion != models.CourseSchemaVersion {
+		return nil, nil
+	}
+	return &snap, nil
+}
+
+// loadSnapshotList reads the last saved list of snapshot names, it returns nil if there is none.
+func (d *diskStore) loadSnapshotList() ([]string, error) {
+	if d.dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(d.dir, snapshotListFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (d *diskStore) saveSnapshotList(names []string) error {
+	if d.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return d.writeFile(filepath.Join(d.dir, snapshotListFile), data)
+}
+
+func (d *diskStore) saveSnapshot(snap *snapshot) error {
+	if d.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return d.writeFile(filepath.Join(d.dir, snapshotFile), data)
+}
+
+// writeFile replaces path atomically, so a crash never leaves a half written file behind.
+// Every write gets its own temporary file, concurrent writes of the same path do not mix.
+func (d *diskStore) writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
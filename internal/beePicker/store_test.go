@@ -0,0 +1,43 @@
+package beepicker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	d := &diskStore{dir: dir}
+	path := filepath.Join(dir, "files", "run1", "BLG.json")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.writeFile(path, []byte(strings.Repeat(fmt.Sprint(i%10), 4096))); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4096 || strings.Trim(string(data), string(data[:1])) != "" {
+		t.Errorf("writes were mixed, got %d bytes starting with %q", len(data), data[:min(len(data), 8)])
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %d files in the directory", len(entries))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0644 {
+		t.Errorf("permissions = %v, want 0644", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,82 @@
+// Package apperr defines the errors BeeHub reports to its clients.
+//
+// Services return (or wrap) one of the sentinel errors below, handlers pass
+// whatever they get to Respond, which turns it into the JSON error envelope.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Error is an error with a stable code and the HTTP status it is reported with.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Err     error
+	// RetryAfter is sent as the Retry-After header when set.
+	RetryAfter time.Duration
+}
+
+var (
+	ErrBadRequest            = &Error{Code: "bad_request", Status: http.StatusBadRequest, Message: "bad request"}
+	ErrUnauthenticated       = &Error{Code: "unauthenticated", Status: http.StatusUnauthorized, Message: "unauthenticated"}
+	ErrInvalidCredentials    = &Error{Code: "invalid_credentials", Status: http.StatusUnauthorized, Message: "invalid username or password"}
+	ErrSessionExpired        = &Error{Code: "session_expired", Status: http.StatusUnauthorized, Message: "session expired, please log in again"}
+	ErrNotFound              = &Error{Code: "not_found", Status: http.StatusNotFound, Message: "not found"}
+	ErrConflict              = &Error{Code: "conflict", Status: http.StatusConflict, Message: "conflict"}
+	ErrTooManyRequests       = &Error{Code: "too_many_requests", Status: http.StatusTooManyRequests, Message: "too many attempts, try again later"}
+	ErrUpstreamUnavailable   = &Error{Code: "upstream_unavailable", Status: http.StatusBadGateway, Message: "upstream service unavailable"}
+	ErrUpstreamSchemaChanged = &Error{Code: "upstream_schema_changed", Status: http.StatusBadGateway, Message: "upstream service returned an unexpected response"}
+	ErrUnsupported           = &Error{Code: "unsupported", Status: http.StatusNotImplemented, Message: "not supported on this platform"}
+	ErrInternal              = &Error{Code: "internal", Status: http.StatusInternalServerError, Message: "internal server error"}
+)
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports errors with the same code as equal, so wrapped copies match their sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err.
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// WithMessage returns a copy of the error with a more specific message for the client.
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// WithRetryAfter returns a copy of the error telling the client when to try again.
+func (e *Error) WithRetryAfter(retryAfter time.Duration) *Error {
+	copied := *e
+	copied.RetryAfter = retryAfter
+	return &copied
+}
+
+// From returns the *Error in err's chain, or ErrInternal wrapping err if there is none.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
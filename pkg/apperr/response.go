@@ -0,0 +1,70 @@
+package apperr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID, it is reused when the client sends one.
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "beehub.requestID"
+
+// Envelope is the body of every error response.
+type Envelope struct {
+	Error Body `json:"error"`
+}
+
+// Body describes the error, Code is stable and meant for programs, Message for people.
+type Body struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// RequestID assigns every request an ID, returned in the X-Request-ID header and error envelopes.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// Recovery turns a panicking handler into an internal error response instead of a dropped connection.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		Respond(c, ErrInternal.Wrap(fmt.Errorf("panic: %v", recovered)))
+	})
+}
+
+// Respond writes the error envelope for err and aborts the request.
+// Server side failures are logged with their cause, the client only sees the code and message.
+func Respond(c *gin.Context, err error) {
+	appErr := From(err)
+	requestID := c.GetString(requestIDKey)
+	if appErr.Status >= 500 {
+		log.Printf("[%s] %s %s: %v", requestID, c.Request.Method, c.Request.URL.Path, err)
+	}
+	if appErr.RetryAfter > 0 {
+		seconds := int(math.Ceil(appErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+	c.AbortWithStatusJSON(appErr.Status, Envelope{Error: Body{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		RequestID: requestID,
+	}})
+}
@@ -1 +1,152 @@
 package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings of the backend. Every field can be
+// overridden with an environment variable (or the .env file).
+type Config struct {
+	// SessionTTL is the absolute lifetime of a BeeHub session.
+	SessionTTL time.Duration
+	// SessionIdleTimeout ends sessions that have not been used for this long.
+	SessionIdleTimeout time.Duration
+	// MaxSessions caps the number of live sessions, the least recently used
+	// session is evicted when it is reached.
+	MaxSessions int
+
+	// TokenSecret is the HMAC key BeeHub tokens are signed with.
+	TokenSecret []byte
+	// TokenTTL is the lifetime of a BeeHub token before it has to be refreshed.
+	TokenTTL time.Duration
+
+	// KeplerBaseURL is the address of Kepler, it can point at a local stand-in.
+	KeplerBaseURL string
+	// KeplerRefreshAhead is how long before its expiry a Kepler JWT is renewed in the background.
+	KeplerRefreshAhead time.Duration
+	// KeplerTermID is the current academic term while the term list cannot be read from Kepler.
+	KeplerTermID int
+
+	// ProfileCacheTTL is how long the profile and photo of a student are served from the session.
+	ProfileCacheTTL time.Duration
+
+	// CourseSources are the course data sources ("github", "sis", "local") in the order
+	// they are tried, CourseLocalDir is the folder the local source reads.
+	CourseSources  []string
+	CourseLocalDir string
+	// CatalogDir keeps the downloaded course catalog across restarts, empty keeps it in memory only.
+	CatalogDir string
+	// CatalogWorkers branch files are downloaded at the same time, each attempt
+	// limited to CatalogRequestTimeout and retried CatalogRetries times.
+	CatalogWorkers        int
+	CatalogRequestTimeout time.Duration
+	CatalogRetries        int
+	// CatalogMaxFailedBranches is the number of branches a catalog refresh may miss
+	// and still replace the catalog being served.
+	CatalogMaxFailedBranches int
+	// GitHubToken raises the GitHub API rate limit for listing the course snapshots, it is optional.
+	GitHubToken string
+
+	// LoginIPAttempts and LoginUserAttempts cap the login attempts per client IP
+	// and per username within LoginWindow.
+	LoginWindow       time.Duration
+	LoginIPAttempts   int
+	LoginUserAttempts int
+	// LoginIPFailures and LoginUserFailures failed logins in a row lock the client IP
+	// or username out for LoginLockout, doubling with every lockout up to LoginMaxLockout.
+	LoginIPFailures   int
+	LoginUserFailures int
+	LoginLockout      time.Duration
+	LoginMaxLockout   time.Duration
+	// TrustedProxies may set X-Forwarded-For, the client IP is the peer address otherwise.
+	TrustedProxies []string
+
+	// DebugAddr serves the runtime metrics at /debug/vars, empty disables them. It should
+	// stay a loopback address, the metrics are not protected.
+	DebugAddr string
+}
+
+// Load reads the configuration from the environment, falling back to the defaults.
+func Load() *Config {
+	return &Config{
+		SessionTTL:               getDuration("BEEHUB_SESSION_TTL", 12*time.Hour),
+		SessionIdleTimeout:       getDuration("BEEHUB_SESSION_IDLE_TIMEOUT", 2*time.Hour),
+		MaxSessions:              getInt("BEEHUB_MAX_SESSIONS", 1000),
+		TokenSecret:              getSecret("BEEHUB_TOKEN_SECRET"),
+		TokenTTL:                 getDuration("BEEHUB_TOKEN_TTL", time.Hour),
+		KeplerBaseURL:            getString("KEPLER_BASE_URL", "https://obs.itu.edu.tr"),
+		KeplerRefreshAhead:       getDuration("KEPLER_REFRESH_AHEAD", 5*time.Minute),
+		KeplerTermID:             getInt("KEPLER_TERM_ID", 759),
+		ProfileCacheTTL:          getDuration("BEEHUB_PROFILE_CACHE_TTL", 10*time.Minute),
+		CourseSources:            getList("BEEHUB_COURSE_SOURCES"),
+		CourseLocalDir:           getString("BEEHUB_COURSE_LOCAL_DIR", ""),
+		CatalogDir:               getString("BEEHUB_CATALOG_DIR", ".beehub-catalog"),
+		CatalogWorkers:           getInt("BEEHUB_CATALOG_WORKERS", 8),
+		CatalogRequestTimeout:    getDuration("BEEHUB_CATALOG_REQUEST_TIMEOUT", 15*time.Second),
+		CatalogRetries:           getInt("BEEHUB_CATALOG_RETRIES", 3),
+		CatalogMaxFailedBranches: getInt("BEEHUB_CATALOG_MAX_FAILED_BRANCHES", 0),
+		GitHubToken:              getString("BEEHUB_GITHUB_TOKEN", ""),
+		LoginWindow:              getDuration("BEEHUB_LOGIN_WINDOW", time.Minute),
+		LoginIPAttempts:          getInt("BEEHUB_LOGIN_IP_ATTEMPTS", 20),
+		LoginUserAttempts:        getInt("BEEHUB_LOGIN_USER_ATTEMPTS", 5),
+		LoginIPFailures:          getInt("BEEHUB_LOGIN_IP_FAILURES", 20),
+		LoginUserFailures:        getInt("BEEHUB_LOGIN_USER_FAILURES", 3),
+		LoginLockout:             getDuration("BEEHUB_LOGIN_LOCKOUT", time.Minute),
+		LoginMaxLockout:          getDuration("BEEHUB_LOGIN_MAX_LOCKOUT", time.Hour),
+		TrustedProxies:           getList("BEEHUB_TRUSTED_PROXIES"),
+		DebugAddr:                getString("BEEHUB_DEBUG_ADDR", "127.0.0.1:6060"),
+	}
+}
+
+// getSecret returns the secret in the given variable.
+// If it is not set a random one is generated, so tokens do not survive a restart.
+func getSecret(key string) []byte {
+	if value := getString(key, ""); value != "" {
+		return []byte(value)
+	}
+	fmt.Printf("%s is not set, using a random secret\n", key)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+func getString(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getList reads a comma separated list.
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(getString(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getString(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getString(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package itulogin
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+
+	"golang.org/x/net/html"
+)
+
+// formFields returns the name and value of every hidden input of the page.
+// Other inputs are left out, ASP.NET treats a posted submit button as clicked.
+func formFields(r io.Reader) (url.Values, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := url.Values{}
+	walk(doc, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "input" && attr(n, "type") == "hidden" {
+			if name := attr(n, "name"); name != "" {
+				fields.Set(name, attr(n, "value"))
+			}
+		}
+		return true
+	})
+	return fields, nil
+}
+
+// isLoggedIn reports whether the token endpoint answered with a token.
+// When the user is not logged in Kepler answers with the login page, so a
+// response containing a form means the login failed.
+func isLoggedIn(body []byte) (bool, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	hasLoginForm := false
+	walk(doc, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "form" {
+			hasLoginForm = true
+			return false
+		}
+		return true
+	})
+	return !hasLoginForm, nil
+}
+
+// walk visits the nodes of the tree depth first until visit returns false.
+func walk(n *html.Node, visit func(*html.Node) bool) bool {
+	if !visit(n) {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !walk(c, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
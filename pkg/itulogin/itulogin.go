@@ -0,0 +1,166 @@
+// Package itulogin performs the ITU single sign-on login of Kepler (obs.itu.edu.tr).
+//
+// The SSO is an ASP.NET form: the login page is fetched to collect its hidden
+// fields (__VIEWSTATE, __EVENTVALIDATION, ...), the form is posted back with the
+// credentials and the Kepler JWT is read from /ogrenci/auth/jwt.
+package itulogin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	tokenPath = "/ogrenci/auth/jwt"
+	appName   = "Öğrenci Bilgi Sistemi"
+	userAgent = "BeeHub"
+
+	userNameField = "ctl00$ContentPlaceHolder1$tbUserName"
+	passwordField = "ctl00$ContentPlaceHolder1$tbPassword"
+	loginButton   = "ctl00$ContentPlaceHolder1$btnLogin"
+	appNameField  = "ctl00$ContentPlaceHolder1$hfAppName"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the SSO rejects the username or password.
+	ErrInvalidCredentials = errors.New("itulogin: invalid username or password")
+	// ErrNoRedirect is returned when Kepler does not redirect to the SSO.
+	ErrNoRedirect = errors.New("itulogin: no redirect to the login page")
+	// ErrFormNotFound is returned when the login page has no ASP.NET form fields.
+	ErrFormNotFound = errors.New("itulogin: login form not found")
+)
+
+// StatusError is returned when a step of the login answers with an unexpected HTTP status.
+type StatusError struct {
+	Step       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("itulogin: %s answered with status %d", e.Step, e.StatusCode)
+}
+
+// Session is the result of a successful login.
+type Session struct {
+	// JWT is the Kepler token sent as "Authorization: Bearer" to the Kepler API.
+	JWT string
+	// Cookies are the SSO and Kepler cookies of the logged in browser session.
+	Cookies []*http.Cookie
+	// ExpiresAt is the expiry of the JWT.
+	ExpiresAt time.Time
+}
+
+// Client logs in to the Kepler at its base URL.
+type Client struct {
+	baseURL string
+	timeout time.Duration
+}
+
+// New creates a client for the Kepler at baseURL, e.g. https://obs.itu.edu.tr.
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), timeout: timeout}
+}
+
+// Login performs the SSO form flow with the given credentials.
+func (c *Client) Login(username, password string) (*Session, error) {
+	// Every login gets its own cookie jar so sessions never leak between users
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Jar: jar, Timeout: c.timeout}
+
+	// Kepler redirects to the SSO, the form is posted to the URL that redirected to the login page
+	resp, err := c.send(client, "GET", c.baseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.Request.Response == nil {
+		return nil, ErrNoRedirect
+	}
+	loginURL := resp.Request.Response.Request.URL.String()
+
+	// Login sayfasındaki gizli form alanlarını topla
+	resp, err = c.send(client, "GET", loginURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Step: "login page", StatusCode: resp.StatusCode}
+	}
+	fields, err := formFields(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if fields.Get("__VIEWSTATE") == "" || fields.Get("__EVENTVALIDATION") == "" {
+		return nil, ErrFormNotFound
+	}
+
+	// Formu kullanıcı bilgileriyle gönder
+	fields.Set("__EVENTTARGET", "")
+	fields.Set("__EVENTARGUMENT", "")
+	fields.Set(appNameField, appName)
+	fields.Set(userNameField, username)
+	fields.Set(passwordField, password)
+	fields.Set(loginButton, "Giriş / Login")
+
+	resp, err = c.send(client, "POST", loginURL, strings.NewReader(fields.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Step: "login form", StatusCode: resp.StatusCode}
+	}
+
+	// JWT'yi al, giriş başarısızsa login sayfası döner
+	resp, err = c.send(client, "GET", c.baseURL+tokenPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Step: "token", StatusCode: resp.StatusCode}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	loggedIn, err := isLoggedIn(body)
+	if err != nil {
+		return nil, err
+	}
+	if !loggedIn {
+		return nil, ErrInvalidCredentials
+	}
+
+	jwt := strings.TrimSpace(string(body))
+	session := &Session{JWT: jwt}
+	if base, err := url.Parse(c.baseURL); err == nil {
+		session.Cookies = jar.Cookies(base)
+	}
+	if expiresAt, err := ParseExpiry(jwt); err == nil {
+		session.ExpiresAt = expiresAt
+	}
+	return session, nil
+}
+
+func (c *Client) send(client *http.Client, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,198 @@
+package kepler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/itulogin"
+)
+
+// Kepler endpoints, relative to the base URL
+const (
+	photoPath          = "/api/ogrenci/OgrenciFotograf"
+	academicStatusPath = "/api/ogrenci/AkademikDurum/" // followed by the term ID
+	termsPath          = "/api/ogrenci/AkademikDurum/DonemListesi"
+	personalInfoPath   = "/api/ogrenci/KisiselBilgiler"
+	transcriptPath     = "/api/ogrenci/Belgeler/TranskriptIngilizceOnizleme"
+	registerPath       = "/api/ders-kayit/v21"
+	registerPagePath   = "/ogrenci/DersKayitIslemleri/DersKayit"
+)
+
+// DefaultBaseURL is the address of the real Kepler.
+const DefaultBaseURL = "https://obs.itu.edu.tr"
+
+// KeplerClient is every Kepler (obs.itu.edu.tr) operation BeeHub uses.
+// Methods taking a token return ErrUnauthorized when Kepler rejects it,
+// other failures are reported as apperr.ErrUpstreamUnavailable and responses
+// that cannot be decoded as apperr.ErrUpstreamSchemaChanged.
+type KeplerClient interface {
+	// Login performs the ITU SSO login and returns the Kepler JWT.
+	// Rejected credentials are reported as apperr.ErrInvalidCredentials.
+	Login(username, password string) (string, error)
+	// Profile returns the personal information (KisiselBilgiler) of the student.
+	Profile(token string) (*PersonalInfoResponse, error)
+	// Photo returns the photo (OgrenciFotograf) of the student.
+	Photo(token string) (*PhotoResponse, error)
+	// Terms returns the academic terms (DonemListesi) of the student.
+	Terms(token string) (*TermsResponse, error)
+	// AcademicStatus returns the class level and GPA (AkademikDurum) of the student in a term.
+	AcademicStatus(token string, termID int) (*AcademicStatusResponse, error)
+	// Transcript returns the English transcript preview of the student.
+	Transcript(token string) ([]byte, error)
+	// Register adds the ecrn and drops the scrn CRNs in one ders-kayit request.
+	Register(token string, ecrn, scrn []string) (*RegisterResponse, error)
+}
+
+// HTTPClient is the KeplerClient talking to a Kepler instance over HTTP.
+type HTTPClient struct {
+	baseURL string
+	http    *http.Client
+	login   *itulogin.Client
+}
+
+var _ KeplerClient = (*HTTPClient)(nil)
+
+// NewHTTPClient creates a client for the Kepler at baseURL,
+// e.g. DefaultBaseURL or the address of a local stand-in.
+func NewHTTPClient(baseURL string) *HTTPClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	timeout := 30 * time.Second
+	return &HTTPClient{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: timeout},
+		login:   itulogin.New(baseURL, timeout),
+	}
+}
+
+// Login performs the ITU SSO login and returns the Kepler JWT.
+func (c *HTTPClient) Login(username, password string) (string, error) {
+	session, err := c.login.Login(username, password)
+	if errors.Is(err, itulogin.ErrInvalidCredentials) {
+		return "", apperr.ErrInvalidCredentials.Wrap(err)
+	}
+	if err != nil {
+		return "", apperr.ErrUpstreamUnavailable.Wrap(err)
+	}
+	return session.JWT, nil
+}
+
+func (c *HTTPClient) Profile(token string) (*PersonalInfoResponse, error) {
+	var response PersonalInfoResponse
+	if err := c.getJSON(personalInfoPath, personalInfoPath, token, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Photo(token string) (*PhotoResponse, error) {
+	var response PhotoResponse
+	if err := c.getJSON(photoPath, photoPath, token, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Terms(token string) (*TermsResponse, error) {
+	var response TermsResponse
+	if err := c.getJSON(termsPath, termsPath, token, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) AcademicStatus(token string, termID int) (*AcademicStatusResponse, error) {
+	var response AcademicStatusResponse
+	path := academicStatusPath + strconv.Itoa(termID)
+	if err := c.getJSON(path, academicStatusPath+"{id}", token, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *HTTPClient) Transcript(token string) ([]byte, error) {
+	return c.get(transcriptPath, token)
+}
+
+func (c *HTTPClient) Register(token string, ecrn, scrn []string) (*RegisterResponse, error) {
+	if ecrn == nil {
+		ecrn = []string{}
+	}
+	if scrn == nil {
+		scrn = []string{}
+	}
+	payload, err := json.Marshal(map[string][]string{
+		"ECRN": ecrn, // CRNs to be added
+		"SCRN": scrn, // CRNs to be deleted
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+registerPath, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Origin", c.baseURL)
+	req.Header.Set("Referer", c.baseURL+registerPagePath)
+	body, err := c.do(req, token)
+	if err != nil {
+		return nil, err
+	}
+	var response RegisterResponse
+	if err := Decode(registerPath, body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// get sends an authorized GET request and returns the body.
+func (c *HTTPClient) get(path, token string) ([]byte, error) {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req, token)
+}
+
+// getJSON sends an authorized GET request and decodes the response into v.
+// endpoint names the endpoint in schema drift reports.
+func (c *HTTPClient) getJSON(path, endpoint, token string, v any) error {
+	body, err := c.get(path, token)
+	if err != nil {
+		return err
+	}
+	return Decode(endpoint, body, v)
+}
+
+func (c *HTTPClient) do(req *http.Request, token string) ([]byte, error) {
+	req.Header.Set("User-Agent", "BeeHub")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, apperr.ErrUpstreamUnavailable.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperr.ErrUpstreamUnavailable.Wrap(fmt.Errorf("%s answered with status %d", req.URL.Path, resp.StatusCode))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, apperr.ErrUpstreamUnavailable.Wrap(err)
+	}
+	return body, nil
+}
@@ -0,0 +1,107 @@
+package kepler
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+)
+
+// reportedDrift remembers the drift already logged, so every change is logged once per process.
+var reportedDrift sync.Map
+
+// Decode unmarshals a Kepler response into v, a pointer to one of the response types.
+//
+// Kepler changes without notice, so the payload is also compared with v: fields
+// Kepler sends that v does not know and expected fields (the ones without
+// omitempty) that Kepler left out are logged as schema drift. A body that cannot
+// be decoded at all is reported as apperr.ErrUpstreamSchemaChanged.
+func Decode(endpoint string, body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return apperr.ErrUpstreamSchemaChanged.Wrap(err)
+	}
+
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+	var unknown, missing []string
+	compareSchema(reflect.TypeOf(v).Elem(), raw, "", &unknown, &missing)
+	reportDrift(endpoint, "unknown", unknown)
+	reportDrift(endpoint, "missing", missing)
+	return nil
+}
+
+func reportDrift(endpoint, kind string, fields []string) {
+	sort.Strings(fields)
+	for _, field := range fields {
+		if _, seen := reportedDrift.LoadOrStore(endpoint+" "+kind+" "+field, true); !seen {
+			log.Printf("Kepler schema drift in %s: %s field %q", endpoint, kind, field)
+		}
+	}
+}
+
+// compareSchema walks the decoded JSON value alongside the Go type it was decoded into.
+func compareSchema(t reflect.Type, value any, path string, unknown, missing *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		known := make(map[string]bool)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, optional := jsonName(field)
+			if name == "" {
+				continue
+			}
+			known[name] = true
+			fieldValue, present := object[name]
+			if !present {
+				if !optional {
+					*missing = append(*missing, path+name)
+				}
+				continue
+			}
+			compareSchema(field.Type, fieldValue, path+name+".", unknown, missing)
+		}
+		for name := range object {
+			if !known[name] {
+				*unknown = append(*unknown, path+name)
+			}
+		}
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for _, item := range items {
+			compareSchema(t.Elem(), item, strings.TrimSuffix(path, ".")+"[].", unknown, missing)
+		}
+	}
+}
+
+// jsonName returns the JSON name of a struct field and whether it is tagged omitempty.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(options, "omitempty")
+}
@@ -0,0 +1,134 @@
+package kepler
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// Kepler payloads. Fields tagged omitempty are not sent for every student,
+// the others are expected in every response (see Decode).
+
+// PersonalInfoResponse is returned by KisiselBilgiler.
+type PersonalInfoResponse struct {
+	PersonalInfo PersonalInfo `json:"kisiselBilgiler"`
+}
+
+type PersonalInfo struct {
+	FullName      string `json:"adSoyad"`
+	FirstName     string `json:"ad,omitempty"`
+	LastName      string `json:"soyad,omitempty"`
+	StudentNumber string `json:"ogrenciNo"`
+	Email         string `json:"ePosta"`
+	Gender        string `json:"cinsiyet,omitempty"`
+	Faculty       string `json:"fakulteEN"`
+	Department    string `json:"bolumAdiEN"`
+	Program       string `json:"programAdiEN,omitempty"`
+	Advisor       string `json:"danismanAdSoyad,omitempty"`
+}
+
+// PhotoResponse is returned by OgrenciFotograf.
+type PhotoResponse struct {
+	Base64 string `json:"base64Fotograf"`
+}
+
+// AcademicStatusResponse is returned by AkademikDurum.
+type AcademicStatusResponse struct {
+	AcademicStatus AcademicStatus `json:"akademikDurum"`
+}
+
+type AcademicStatus struct {
+	// ClassLevel reads like "3. Sınıf"
+	ClassLevel string  `json:"sinifSeviye"`
+	GPA        float64 `json:"genelNotOrtalamasi"`
+}
+
+// TermsResponse is returned by DonemListesi.
+type TermsResponse struct {
+	Terms []Term `json:"donemListesi"`
+}
+
+type Term struct {
+	ID      int    `json:"akademikDonemId"`
+	Code    string `json:"akademikDonemKodu"`
+	Name    string `json:"akademikDonemAdi"`
+	Current bool   `json:"guncelDonemMi"`
+}
+
+// UnmarshalJSON accepts the term list as the response itself or under any field.
+// Kepler has used different shapes and field names for DonemListesi over time.
+func (r *TermsResponse) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Terms); err == nil {
+		return nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	if raw, ok := object["donemListesi"]; ok {
+		return json.Unmarshal(raw, &r.Terms)
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var terms []Term
+		if err := json.Unmarshal(object[key], &terms); err == nil && terms != nil {
+			r.Terms = terms
+			return nil
+		}
+	}
+	return errors.New("term list not found")
+}
+
+// UnmarshalJSON accepts every known spelling of the term fields, IDs may be strings.
+func (t *Term) UnmarshalJSON(data []byte) error {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	switch id := firstField(fields, "akademikDonemId", "donemId", "id").(type) {
+	case float64:
+		t.ID = int(id)
+	case string:
+		t.ID, _ = strconv.Atoi(id)
+	}
+	switch code := firstField(fields, "akademikDonemKodu", "donemKodu", "kod").(type) {
+	case string:
+		t.Code = code
+	case float64:
+		t.Code = strconv.Itoa(int(code))
+	}
+	t.Name, _ = firstField(fields, "akademikDonemAdi", "akademikDonemAdiEN", "donemAdi", "ad").(string)
+	t.Current, _ = firstField(fields, "guncelDonemMi", "aktifDonemMi", "aktif").(bool)
+	return nil
+}
+
+// firstField returns the value of the first of the keys present in fields.
+func firstField(fields map[string]any, keys ...string) any {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok && value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// RegisterResponse is returned by ders-kayit, one result per requested CRN.
+type RegisterResponse struct {
+	ECRNResultList []CRNResult `json:"ecrnResultList"`
+	SCRNResultList []CRNResult `json:"scrnResultList"`
+}
+
+type CRNResult struct {
+	CRN          string `json:"crn"`
+	OperationFin bool   `json:"operationFin"`
+	// StatusCode is 0 when the operation succeeded
+	StatusCode int    `json:"statusCode"`
+	ResultCode string `json:"resultCode"`
+	// ResultData is filled in by BeeHub with the explanation of ResultCode
+	ResultData *string `json:"resultData"`
+}
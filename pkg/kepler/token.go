@@ -0,0 +1,203 @@
+package kepler
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/itulogin"
+)
+
+// ErrUnauthorized is returned by requests that Kepler answered with 401.
+var ErrUnauthorized = errors.New("kepler rejected the token")
+
+// fallbackLifetime is used when the expiry of a token cannot be read.
+const fallbackLifetime = 4 * time.Hour
+
+// retryInterval is how long the background refresh waits after a failed login.
+const retryInterval = time.Minute
+
+// LoginFunc logs in to Kepler and returns a fresh JWT.
+type LoginFunc func() (string, error)
+
+// TokenKeeper owns the Kepler JWT of one user. It renews the token in the
+// background shortly before it expires and retries requests rejected with 401 once.
+type TokenKeeper struct {
+	login        LoginFunc
+	refreshAhead time.Duration
+
+	refreshMu sync.Mutex // serializes logins
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	timer     *time.Timer
+	stopped   bool
+	// rejected is the login error after the credentials were rejected, the keeper
+	// never logs in again then, retrying could get the ITU account locked
+	rejected error
+}
+
+// NewTokenKeeper creates a keeper for an already obtained token. An empty token
+// is renewed on first use.
+func NewTokenKeeper(token string, refreshAhead time.Duration, login LoginFunc) *TokenKeeper {
+	k := &TokenKeeper{login: login, refreshAhead: refreshAhead}
+	k.set(token)
+	return k
+}
+
+// Token returns a valid token, logging in again first if the current one has expired.
+func (k *TokenKeeper) Token() (string, error) {
+	k.mu.Lock()
+	token, expiresAt := k.token, k.expiresAt
+	k.mu.Unlock()
+
+	if token != "" && time.Now().Before(expiresAt) {
+		return token, nil
+	}
+	return k.refresh(token)
+}
+
+// ExpiresAt returns the expiry of the current token.
+func (k *TokenKeeper) ExpiresAt() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.expiresAt
+}
+
+// Refresh logs in again and replaces the current token.
+func (k *TokenKeeper) Refresh() (string, error) {
+	k.mu.Lock()
+	token := k.token
+	k.mu.Unlock()
+	return k.refresh(token)
+}
+
+// Do calls fn with the current token. If fn returns ErrUnauthorized the token
+// is renewed and fn is called once more, a second rejection is reported as
+// apperr.ErrSessionExpired.
+func (k *TokenKeeper) Do(fn func(token string) error) error {
+	token, err := k.Token()
+	if err != nil {
+		return err
+	}
+	err = fn(token)
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	token, err = k.refresh(token)
+	if err != nil {
+		return err
+	}
+	err = fn(token)
+	if errors.Is(err, ErrUnauthorized) {
+		return apperr.ErrSessionExpired.Wrap(err)
+	}
+	return err
+}
+
+// Start schedules the background renewal of the token.
+func (k *TokenKeeper) Start() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.stopped = false
+	k.schedule(k.untilRefresh())
+}
+
+// Stop cancels the background renewal.
+func (k *TokenKeeper) Stop() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.stopped = true
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+}
+
+// refresh logs in unless another caller already replaced the stale token.
+// Once the credentials were rejected it fails with apperr.ErrSessionExpired
+// without logging in, the user has to log in again.
+func (k *TokenKeeper) refresh(stale string) (string, error) {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	k.mu.Lock()
+	current, rejected := k.token, k.rejected
+	k.mu.Unlock()
+	if rejected != nil {
+		return "", apperr.ErrSessionExpired.Wrap(rejected)
+	}
+	if current != stale && current != "" {
+		return current, nil
+	}
+
+	token, err := k.login()
+	if errors.Is(err, itulogin.ErrInvalidCredentials) {
+		k.mu.Lock()
+		k.rejected = err
+		k.mu.Unlock()
+		return "", apperr.ErrSessionExpired.Wrap(err)
+	}
+	if err != nil {
+		return "", err
+	}
+	k.set(token)
+	return token, nil
+}
+
+func (k *TokenKeeper) set(token string) {
+	expiresAt, err := itulogin.ParseExpiry(token)
+	if err != nil {
+		expiresAt = time.Now().Add(fallbackLifetime)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.token = token
+	k.expiresAt = expiresAt
+	if token == "" {
+		k.expiresAt = time.Time{}
+	}
+	if k.timer != nil && !k.stopped {
+		k.schedule(k.untilRefresh())
+	}
+}
+
+// untilRefresh returns when the background renewal should run. Tokens living
+// shorter than twice refreshAhead are renewed halfway through their lifetime
+// so short lived tokens do not cause a login loop.
+// It must be called with k.mu held.
+func (k *TokenKeeper) untilRefresh() time.Duration {
+	remaining := time.Until(k.expiresAt)
+	return remaining - min(k.refreshAhead, remaining/2)
+}
+
+// schedule must be called with k.mu held.
+func (k *TokenKeeper) schedule(after time.Duration) {
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+	if after < 0 {
+		after = 0
+	}
+	k.timer = time.AfterFunc(after, k.backgroundRefresh)
+}
+
+func (k *TokenKeeper) backgroundRefresh() {
+	_, err := k.Refresh()
+	if errors.Is(err, itulogin.ErrInvalidCredentials) {
+		log.Printf("Kepler token refresh failed, giving up: %v", err)
+		return
+	}
+	if err != nil {
+		log.Printf("Kepler token refresh failed, retrying in %s: %v", retryInterval, err)
+		k.mu.Lock()
+		if !k.stopped {
+			k.schedule(retryInterval)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,79 @@
+package kepler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/apperr"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/itulogin"
+)
+
+func TestTokenKeeperRejectedCredentials(t *testing.T) {
+	logins := 0
+	k := NewTokenKeeper("", time.Minute, func() (string, error) {
+		logins++
+		return "", apperr.ErrInvalidCredentials.Wrap(itulogin.ErrInvalidCredentials)
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := k.Token(); !errors.Is(err, apperr.ErrSessionExpired) {
+			t.Fatalf("Token() error = %v, want ErrSessionExpired", err)
+		}
+	}
+	err := k.Do(func(string) error {
+		t.Error("Do called fn without a token")
+		return nil
+	})
+	if !errors.Is(err, apperr.ErrSessionExpired) {
+		t.Errorf("Do() error = %v, want ErrSessionExpired", err)
+	}
+	if _, err := k.Refresh(); !errors.Is(err, apperr.ErrSessionExpired) {
+		t.Errorf("Refresh() error = %v, want ErrSessionExpired", err)
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+func TestTokenKeeperRejectedOnRetry(t *testing.T) {
+	logins := 0
+	k := NewTokenKeeper("old", time.Minute, func() (string, error) {
+		logins++
+		return "", apperr.ErrInvalidCredentials.Wrap(itulogin.ErrInvalidCredentials)
+	})
+
+	// Every request is rejected with 401, only the first one may try to log in
+	for i := 0; i < 3; i++ {
+		err := k.Do(func(string) error { return ErrUnauthorized })
+		if !errors.Is(err, apperr.ErrSessionExpired) {
+			t.Fatalf("Do() error = %v, want ErrSessionExpired", err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+func TestTokenKeeperTemporaryFailure(t *testing.T) {
+	logins := 0
+	k := NewTokenKeeper("", time.Minute, func() (string, error) {
+		logins++
+		if logins == 1 {
+			return "", apperr.ErrUpstreamUnavailable
+		}
+		return "new", nil
+	})
+
+	if _, err := k.Token(); !errors.Is(err, apperr.ErrUpstreamUnavailable) {
+		t.Fatalf("Token() error = %v, want ErrUpstreamUnavailable", err)
+	}
+	// An outage is not a rejection, the next request logs in again
+	token, err := k.Token()
+	if err != nil || token != "new" {
+		t.Fatalf("Token() = %q, %v, want new", token, err)
+	}
+	if logins != 2 {
+		t.Errorf("logged in %d times, want 2", logins)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+// CourseSchemaVersion is increased whenever the JSON form of the course catalog
+// changes in a way clients have to know about.
+const CourseSchemaVersion = 1
+
+// CourseCatalog is the course offering of a term as served by /beePicker/courses.
+type CourseCatalog struct {
+	SchemaVersion int      `json:"schema_version"`
+	Courses       []Course `json:"courses"`
+}
+
+// Course is a course of the catalog with all of its sections.
+type Course struct {
+	// Code is the branch code and number, e.g. "BLG 101E"
+	Code   string `json:"code"`
+	Branch string `json:"branch"`
+	Number string `json:"number"`
+	Title  string `json:"title"`
+	// Language is "en" for courses taught in English (numbers ending in E), "tr" otherwise
+	Language string    `json:"language"`
+	Credits  float64   `json:"credits,omitempty"`
+	Sections []Section `json:"sections"`
+}
+
+// CoursePage is a page of a course catalog query.
+type CoursePage struct {
+	SchemaVersion int      `json:"schema_version"`
+	Courses       []Course `json:"courses"`
+	// Total is the number of courses matching the query over all pages
+	Total int `json:"total"`
+	// NextCursor fetches the next page, it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CourseMatch is a course found by /beePicker/search, better matches score higher.
+type CourseMatch struct {
+	Score  int    `json:"score"`
+	Course Course `json:"course"`
+}
+
+// CatalogSnapshot is a course offering published by the course source, e.g. the
+// scraper run of a term.
+type CatalogSnapshot struct {
+	Name   string `json:"snapshot"`
+	Source string `json:"source"`
+	// Current is set for the snapshot /beePicker/courses serves by default
+	Current bool `json:"current"`
+}
+
+// CatalogDiff lists the section changes between two catalog snapshots.
+type CatalogDiff struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Added   []SectionRef    `json:"added"`
+	Removed []SectionRef    `json:"removed"`
+	Changed []SectionChange `json:"changed"`
+}
+
+// SectionRef names a section in a CatalogDiff.
+type SectionRef struct {
+	CRN   string `json:"crn"`
+	Code  string `json:"code"`
+	Title string `json:"title"`
+}
+
+// SectionChange is a section found in both snapshots of a CatalogDiff with different values.
+type SectionChange struct {
+	SectionRef
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a changed field of a section: instructors, times, rooms or capacity.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ConflictReport lists the overlapping meetings of a set of CRNs.
+type ConflictReport struct {
+	Conflicts []Conflict `json:"conflicts"`
+	// UnknownCRNs are not in the course catalog and could not be checked
+	UnknownCRNs []string `json:"unknown_crns"`
+}
+
+// Conflict is a pair of meetings of different sections that overlap.
+type Conflict struct {
+	First  SectionMeeting `json:"first"`
+	Second SectionMeeting `json:"second"`
+}
+
+// SectionMeeting is a meeting together with the section it belongs to.
+type SectionMeeting struct {
+	CRN  string `json:"crn"`
+	Code string `json:"code"`
+	Meeting
+}
+
+// Section is one CRN of a course.
+type Section struct {
+	CRN            string       `json:"crn"`
+	Instructors    []string     `json:"instructors"`
+	TeachingMethod string       `json:"teaching_method,omitempty"`
+	Meetings       []Meeting    `json:"meetings"`
+	Capacity       int          `json:"capacity"`
+	Enrolled       int          `json:"enrolled"`
+	Restrictions   Restrictions `json:"restrictions"`
+}
+
+// Meeting is a weekly lecture of a section. Start and End are "15:04" in local time.
+type Meeting struct {
+	Day      string `json:"day"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Building string `json:"building,omitempty"`
+	Room     string `json:"room,omitempty"`
+}
+
+// Restrictions limit who can register to a section.
+type Restrictions struct {
+	// Programs are the major codes allowed to register
+	Programs      []string `json:"programs"`
+	Prerequisites string   `json:"prerequisites,omitempty"`
+	ClassLevel    string   `json:"class_level,omitempty"`
+	Reservation   string   `json:"reservation,omitempty"`
+}
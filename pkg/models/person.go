@@ -4,14 +4,17 @@ import "time"
 
 type Person struct {
 	Email             string    `json:"email"`
-	Password          string    `json:"password"`
+	Full_name         string    `json:"full_name"`
 	First_name        string    `json:"first_name"`
 	Last_name         string    `json:"last_name"`
+	Student_number    string    `json:"student_number"`
 	Photo_base64      string    `json:"photo_location"`
 	Token             string    `json:"token"`
 	Class             string    `json:"class"`
 	Faculty           string    `json:"faculty"`
 	Department        string    `json:"departmant"`
+	Program           string    `json:"program"`
+	Advisor           string    `json:"advisor"`
 	Gender            string    `json:"gender"`
 	GPA               string    `json:"gpa"`
 	Transcript_base64 string    `json:"transcript"`
@@ -2,25 +2,34 @@ package models
 
 // PersonDTO struct with selected attributes
 type PersonDTO struct {
-	Email        string `json:"email"`
-	First_name   string `json:"first_name"`
-	Last_name    string `json:"last_name"`
-	Faculty      string `json:"faculty"`
-	Department   string `json:"department"`
-	GPA          string `json:"gpa"`
-	Photo_base64 string `json:"photo"`
-	Class        string `json:"class"`
+	Email          string `json:"email"`
+	Full_name      string `json:"full_name"`
+	First_name     string `json:"first_name"`
+	Last_name      string `json:"last_name"`
+	Student_number string `json:"student_number"`
+	Faculty        string `json:"faculty"`
+	Department     string `json:"department"`
+	Program        string `json:"program"`
+	Advisor        string `json:"advisor"`
+	Gender         string `json:"gender"`
+	GPA            string `json:"gpa"`
+	Photo_url      string `json:"photo_url"`
+	Class          string `json:"class"`
 }
 
 func ToPersonDTO(person Person) PersonDTO {
 	return PersonDTO{
-		Email:        person.Email,
-		First_name:   person.First_name,
-		Last_name:    person.Last_name,
-		Faculty:      person.Faculty,
-		Department:   person.Department,
-		GPA:          person.GPA,
-		Photo_base64: person.Photo_base64,
-		Class:        person.Class,
+		Email:          person.Email,
+		Full_name:      person.Full_name,
+		First_name:     person.First_name,
+		Last_name:      person.Last_name,
+		Student_number: person.Student_number,
+		Faculty:        person.Faculty,
+		Department:     person.Department,
+		Program:        person.Program,
+		Advisor:        person.Advisor,
+		Gender:         person.Gender,
+		GPA:            person.GPA,
+		Class:          person.Class,
 	}
 }
@@ -0,0 +1,38 @@
+package models
+
+// GeneratedSchedules are the conflict-free schedules of a course wish list, best first.
+type GeneratedSchedules struct {
+	// Total is the number of conflict-free schedules found
+	Total int `json:"total"`
+	// Truncated is set when the search stopped early because there were too many schedules,
+	// Total and the ranking then only cover the schedules found until then
+	Truncated bool                `json:"truncated"`
+	Schedules []GeneratedSchedule `json:"schedules"`
+}
+
+// GeneratedSchedule is one section of every course of the wish list without overlapping meetings.
+type GeneratedSchedule struct {
+	// Name is set when the schedule was saved to the user's schedules
+	Name     string             `json:"name,omitempty"`
+	Score    int                `json:"score"`
+	CRNs     []string           `json:"crns"`
+	Sections []ScheduledSection `json:"sections"`
+
+	// EarlyMeetings start before the preferred earliest time
+	EarlyMeetings int `json:"early_meetings"`
+	// FreeDays are the weekdays without a meeting
+	FreeDays []string `json:"free_days"`
+	// GapHours are the idle hours between the meetings of a day, summed over the week
+	GapHours int `json:"gap_hours"`
+	// PreferredInstructors is the number of sections taught by a preferred instructor
+	PreferredInstructors int `json:"preferred_instructors"`
+	// FullSections is the number of sections without seats left
+	FullSections int `json:"full_sections"`
+}
+
+// ScheduledSection is a section of a GeneratedSchedule together with its course.
+type ScheduledSection struct {
+	Code  string `json:"code"`
+	Title string `json:"title"`
+	Section
+}
@@ -0,0 +1,16 @@
+package models
+
+// Term is an academic term of a student as listed by Kepler.
+type Term struct {
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+// AcademicStatus is the standing of a student in a term.
+type AcademicStatus struct {
+	TermID int     `json:"term_id"`
+	Class  string  `json:"class"`
+	GPA    float64 `json:"gpa"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Transcript is the academic history of a student as read from the Kepler transcript.
+type Transcript struct {
+	Terms         []TranscriptTerm `json:"terms"`
+	TotalCredits  float64          `json:"total_credits"`
+	CumulativeGPA float64          `json:"cumulative_gpa"`
+}
+
+// TranscriptTerm is one semester of the transcript, e.g. "2023-2024 Fall".
+type TranscriptTerm struct {
+	Name          string             `json:"name"`
+	Courses       []TranscriptCourse `json:"courses"`
+	Credits       float64            `json:"credits"`
+	TermGPA       float64            `json:"term_gpa"`
+	CumulativeGPA float64            `json:"cumulative_gpa"`
+}
+
+// TranscriptCourse is a course taken in a term. Grade is empty while the term is in progress.
+type TranscriptCourse struct {
+	Code    string  `json:"code"`
+	Title   string  `json:"title"`
+	Credits float64 `json:"credits"`
+	ECTS    float64 `json:"ects,omitempty"`
+	Grade   string  `json:"grade"`
+}
@@ -18,10 +18,19 @@ func NewPersonManager() *PersonManager {
 	}
 }
 
+// GetPerson returns a copy of the person, use EditPerson to change it.
 func (pm *PersonManager) GetPerson() *models.Person {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	return pm.person
+	person := *pm.person
+	return &person
+}
+
+// EditPerson calls fn with the person while holding the lock.
+func (pm *PersonManager) EditPerson(fn func(person *models.Person)) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	fn(pm.person)
 }
 
 func (pm *PersonManager) UpdatePerson(person *models.Person) {
@@ -112,8 +121,14 @@ func (pm *PersonManager) SetLastName(name string) {
 	pm.person.Last_name = name
 }
 
-func (pm *PersonManager) SetPassword(password string) {
+func (pm *PersonManager) GetTranscript() string {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.person.Transcript_base64
+}
+
+func (pm *PersonManager) SetTranscript(transcript string) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	pm.person.Password = password
+	pm.person.Transcript_base64 = transcript
 }
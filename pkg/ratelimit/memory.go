@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store for a single BeeHub instance.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+	// now is the clock, replaced in tests
+	now func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]State), now: time.Now}
+}
+
+func (s *MemoryStore) Update(key string, fn func(state *State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if ok && s.now().After(state.Expires) {
+		state = State{}
+	}
+	fn(&state)
+	if state.Expires.IsZero() {
+		delete(s.states, key)
+		return
+	}
+	s.states[key] = state
+}
+
+// StartJanitor drops expired states every interval for the lifetime of the process.
+func (s *MemoryStore) StartJanitor(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.dropExpired()
+		}
+	}()
+}
+
+func (s *MemoryStore) dropExpired() {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, state := range s.states {
+		if now.After(state.Expires) {
+			delete(s.states, key)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package ratelimit limits how often a key (an IP address, a username) may attempt
+// something and locks it out with an exponentially growing delay after repeated failures.
+package ratelimit
+
+import "time"
+
+// Policy configures a Limiter.
+type Policy struct {
+	// Attempts is how many attempts are allowed per Window.
+	Attempts int
+	Window   time.Duration
+	// MaxFailures consecutive failures lock the key out. Every further lockout
+	// lasts twice as long as the previous one, from Lockout up to MaxLockout.
+	MaxFailures int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+// State is what a Store keeps per key.
+type State struct {
+	Attempts    int
+	WindowStart time.Time
+	Failures    int
+	Lockouts    int
+	LockedUntil time.Time
+	LastFailure time.Time
+	// Expires is when the state no longer matters and may be dropped by the store.
+	Expires time.Time
+}
+
+// Store keeps the limiter states. Implementations must apply Update atomically
+// so several BeeHub instances can share one store.
+type Store interface {
+	// Update calls fn with the state stored under key and stores the result.
+	// A missing or expired key starts from the zero State.
+	Update(key string, fn func(state *State))
+}
+
+// Limiter applies a Policy to keys in a Store. Keys are prefixed with the limiter
+// name so limiters can share a store.
+type Limiter struct {
+	store  Store
+	name   string
+	policy Policy
+	// now is the clock, replaced in tests
+	now func() time.Time
+}
+
+func NewLimiter(store Store, name string, policy Policy) *Limiter {
+	return &Limiter{store: store, name: name, policy: policy, now: time.Now}
+}
+
+// Allow records an attempt for key. If the key is locked out or has used up its
+// attempts it returns false and how long the caller has to wait.
+func (l *Limiter) Allow(key string) (time.Duration, bool) {
+	var retryAfter time.Duration
+	allowed := true
+	l.store.Update(l.name+":"+key, func(state *State) {
+		now := l.now()
+		if now.Before(state.LockedUntil) {
+			retryAfter, allowed = state.LockedUntil.Sub(now), false
+			return
+		}
+		if now.Sub(state.WindowStart) >= l.policy.Window {
+			state.WindowStart = now
+			state.Attempts = 0
+		}
+		if l.policy.Attempts > 0 && state.Attempts >= l.policy.Attempts {
+			retryAfter, allowed = state.WindowStart.Add(l.policy.Window).Sub(now), false
+			return
+		}
+		state.Attempts++
+		l.setExpiry(state)
+	})
+	return retryAfter, allowed
+}
+
+// Failure records a failed attempt for key and returns the lockout it caused, if any.
+func (l *Limiter) Failure(key string) time.Duration {
+	var lockout time.Duration
+	l.store.Update(l.name+":"+key, func(state *State) {
+		now := l.now()
+		// Failures are forgotten once the key behaved for as long as the longest lockout
+		if now.After(forgetAt(state, l.policy)) {
+			state.Failures = 0
+			state.Lockouts = 0
+		}
+		state.Failures++
+		state.LastFailure = now
+		if l.policy.MaxFailures > 0 && state.Failures >= l.policy.MaxFailures {
+			state.Failures = 0
+			state.Lockouts++
+			lockout = l.lockout(state.Lockouts)
+			state.LockedUntil = now.Add(lockout)
+		}
+		l.setExpiry(state)
+	})
+	return lockout
+}
+
+// Reset forgets everything recorded for key, e.g. after a successful login.
+func (l *Limiter) Reset(key string) {
+	l.store.Update(l.name+":"+key, func(state *State) {
+		*state = State{}
+	})
+}
+
+// lockout returns the duration of the n-th lockout.
+func (l *Limiter) lockout(n int) time.Duration {
+	lockout := l.policy.Lockout
+	for i := 1; i < n && lockout < l.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, l.policy.MaxLockout)
+}
+
+// setExpiry keeps the state until its window and lockout are over and its failures are forgotten.
+func (l *Limiter) setExpiry(state *State) {
+	state.Expires = state.WindowStart.Add(l.policy.Window)
+	if state.LockedUntil.After(state.Expires) {
+		state.Expires = state.LockedUntil
+	}
+	if forget := forgetAt(state, l.policy); forget.After(state.Expires) {
+		state.Expires = forget
+	}
+}
+
+// forgetAt is when the failures of state are forgotten. Time spent locked out does not count.
+func forgetAt(state *State, policy Policy) time.Time {
+	last := state.LastFailure
+	if state.LockedUntil.After(last) {
+		last = state.LockedUntil
+	}
+	return last.Add(policy.MaxLockout)
+}
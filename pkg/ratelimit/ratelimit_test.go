@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a manual clock shared by the limiter and its store.
+type clock struct{ now time.Time }
+
+func (c *clock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(policy Policy) (*Limiter, *MemoryStore, *clock) {
+	c := &clock{now: time.Date(2024, 9, 1, 9, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = func() time.Time { return c.now }
+	l := NewLimiter(store, "login", policy)
+	l.now = store.now
+	return l, store, c
+}
+
+func TestAllowWindow(t *testing.T) {
+	l, _, c := newTestLimiter(Policy{Attempts: 3, Window: time.Minute, MaxLockout: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if _, ok := l.Allow("1.2.3.4"); !ok {
+			t.Fatalf("attempt %d denied", i+1)
+		}
+		c.advance(10 * time.Second)
+	}
+	if retryAfter, ok := l.Allow("1.2.3.4"); ok || retryAfter != 30*time.Second {
+		t.Errorf("Allow() = %s, %v, want 30s, false", retryAfter, ok)
+	}
+	if _, ok := l.Allow("5.6.7.8"); !ok {
+		t.Error("another key was denied")
+	}
+
+	c.advance(30 * time.Second)
+	if _, ok := l.Allow("1.2.3.4"); !ok {
+		t.Error("denied after the window was over")
+	}
+}
+
+func TestFailureLockout(t *testing.T) {
+	policy := Policy{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 5 * time.Minute}
+	tests := []struct {
+		name string
+		// wait is the time between two lockouts, after the lockout is over
+		wait  time.Duration
+		wants []time.Duration
+	}{
+		{"doubling up to the maximum", time.Second, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}},
+		{"forgotten after the longest lockout", 6 * time.Minute, []time.Duration{time.Minute, time.Minute, time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _, c := newTestLimiter(policy)
+			for i, want := range tt.wants {
+				for f := 1; f < policy.MaxFailures; f++ {
+					if lockout := l.Failure("student"); lockout != 0 {
+						t.Fatalf("lockout %d: failure %d locked out for %s", i+1, f, lockout)
+					}
+				}
+				lockout := l.Failure("student")
+				if lockout != want {
+					t.Fatalf("lockout %d = %s, want %s", i+1, lockout, want)
+				}
+
+				c.advance(lockout - time.Second)
+				if retryAfter, ok := l.Allow("student"); ok || retryAfter != time.Second {
+					t.Fatalf("lockout %d: Allow() = %s, %v, want 1s, false", i+1, retryAfter, ok)
+				}
+				c.advance(time.Second)
+				if _, ok := l.Allow("student"); !ok {
+					t.Fatalf("lockout %d: denied after the lockout was over", i+1)
+				}
+				c.advance(tt.wait)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	l, store, _ := newTestLimiter(Policy{Attempts: 1, Window: time.Minute, MaxFailures: 2, Lockout: time.Minute, MaxLockout: time.Hour})
+
+	l.Allow("student")
+	l.Failure("student")
+	l.Reset("student")
+	if len(store.states) != 0 {
+		t.Errorf("Reset() kept %d states", len(store.states))
+	}
+	if _, ok := l.Allow("student"); !ok {
+		t.Error("denied after Reset()")
+	}
+	// The failure before the reset does not count towards a lockout
+	if lockout := l.Failure("student"); lockout != 0 {
+		t.Errorf("locked out for %s after Reset()", lockout)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	l, store, c := newTestLimiter(Policy{Attempts: 1, Window: time.Minute, MaxFailures: 1, Lockout: time.Minute, MaxLockout: 10 * time.Minute})
+	other := NewLimiter(store, "register", l.policy)
+	other.now = l.now
+
+	l.Allow("window")
+	l.Failure("failure")
+	other.Allow("window")
+	if len(store.states) != 3 {
+		t.Fatalf("store has %d states, want 3", len(store.states))
+	}
+
+	// The window is over, the failure is remembered until the longest lockout passed
+	c.advance(time.Minute + time.Second)
+	store.dropExpired()
+	if _, ok := store.states["login:window"]; ok {
+		t.Error("expired window state was kept")
+	}
+	if _, ok := store.states["register:window"]; ok {
+		t.Error("expired window state of another limiter was kept")
+	}
+	if _, ok := store.states["login:failure"]; !ok {
+		t.Fatal("failure state dropped before it was forgotten")
+	}
+
+	// Update ignores an expired state even if the janitor has not dropped it yet
+	c.advance(10 * time.Minute)
+	store.Update("login:failure", func(state *State) {
+		if state.Lockouts != 0 {
+			t.Errorf("Update() got expired state %+v", *state)
+		}
+	})
+	if len(store.states) != 0 {
+		t.Errorf("store has %d states, want 0", len(store.states))
+	}
+}
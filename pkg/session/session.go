@@ -0,0 +1,330 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/kepler"
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CookieName is the name of the cookie carrying the BeeHub token.
+const CookieName = "beehub_token"
+
+// contextKey is the gin context key the current session is stored under.
+const contextKey = "beehub.session"
+
+var ErrNotFound = errors.New("session not found")
+
+// Session holds the state of one logged in user.
+// The person (Kepler token and login time included) is kept in its own PersonManager.
+type Session struct {
+	ID        string
+	Person    *pkg.PersonManager
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	lastSeen time.Time // guarded by Manager.mu
+
+	mu      sync.Mutex
+	tokenID string
+	keeper  *kepler.TokenKeeper
+	terms   []models.Term
+	cache   map[string]cachedValue
+}
+
+type cachedValue struct {
+	value   any
+	expires time.Time
+}
+
+// TokenKeeper returns the keeper of the session's Kepler JWT.
+func (s *Session) TokenKeeper() *kepler.TokenKeeper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keeper
+}
+
+// SetTokenKeeper attaches the keeper of the session's Kepler JWT.
+// It is stopped when the session ends.
+func (s *Session) SetTokenKeeper(keeper *kepler.TokenKeeper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keeper = keeper
+}
+
+// Terms returns the academic terms discovered for the student, nil until they are fetched.
+func (s *Session) Terms() []models.Term {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.terms
+}
+
+// SetTerms caches the academic terms of the student for the lifetime of the session.
+func (s *Session) SetTerms(terms []models.Term) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.terms = terms
+}
+
+// Cached returns the value cached under key if it has not expired yet.
+func (s *Session) Cached(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.cache[key]
+	if !ok || time.Now().After(cached.expires) {
+		return nil, false
+	}
+	return cached.value, true
+}
+
+// Cache keeps value under key for ttl. Cached values die with the session.
+func (s *Session) Cache(key string, value any, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[string]cachedValue)
+	}
+	s.cache[key] = cachedValue{value: value, expires: time.Now().Add(ttl)}
+}
+
+func (s *Session) close() {
+	if keeper := s.TokenKeeper(); keeper != nil {
+		keeper.Stop()
+	}
+}
+
+// Username returns the ITU username the session was logged in with.
+func (s *Session) Username() string {
+	return NormalizeUsername(s.Person.GetEmail())
+}
+
+// NormalizeUsername maps the spellings of an ITU username ("Name@itu.edu.tr", " name") to one.
+func NormalizeUsername(email string) string {
+	username := strings.ToLower(strings.TrimSpace(email))
+	return strings.TrimSuffix(username, "@itu.edu.tr")
+}
+
+// TokenID returns the ID of the only BeeHub token currently accepted for this session.
+func (s *Session) TokenID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenID
+}
+
+// SetTokenID rotates the accepted BeeHub token, invalidating the previous one.
+func (s *Session) SetTokenID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenID = id
+}
+
+// Manager keeps track of every live session keyed by an opaque session ID.
+type Manager struct {
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	ttl         time.Duration
+	idleTimeout time.Duration
+	maxSessions int
+	onRemove    []func(*Session)
+	// now is the clock, replaced in tests
+	now func() time.Time
+}
+
+func NewManager(ttl, idleTimeout time.Duration, maxSessions int) *Manager {
+	return &Manager{
+		sessions:    make(map[string]*Session),
+		ttl:         ttl,
+		idleTimeout: idleTimeout,
+		maxSessions: maxSessions,
+		now:         time.Now,
+	}
+}
+
+// OnRemove registers fn to be called whenever a session ends, be it by logout, expiry or eviction.
+// It is called after the manager is unlocked, so slow work like file I/O does not hold up other requests.
+func (m *Manager) OnRemove(fn func(*Session)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRemove = append(m.onRemove, fn)
+}
+
+// TTL returns the absolute lifetime of new sessions.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Create starts a new session for the given person.
+// If the manager is full the least recently used session is evicted.
+func (m *Manager) Create(person *models.Person) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	personManager := pkg.NewPersonManager()
+	personManager.UpdatePerson(person)
+
+	now := m.now()
+	sess := &Session{
+		ID:        id,
+		Person:    personManager,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+		lastSeen:  now,
+	}
+
+	m.mu.Lock()
+	var removed []*Session
+	if m.maxSessions > 0 && len(m.sessions) >= m.maxSessions {
+		removed = m.removeExpired(now)
+	}
+	if m.maxSessions > 0 && len(m.sessions) >= m.maxSessions {
+		if oldest := m.evictOldest(); oldest != nil {
+			removed = append(removed, oldest)
+		}
+	}
+	m.sessions[id] = sess
+	m.mu.Unlock()
+
+	m.end(removed...)
+	return sess, nil
+}
+
+// Get returns the session with the given ID and marks it as used.
+func (m *Manager) Get(id string) (*Session, error) {
+	m.mu.Lock()
+	sess, ok := m.sessions[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	now := m.now()
+	if m.expired(sess, now) {
+		m.remove(sess)
+		m.mu.Unlock()
+		m.end(sess)
+		return nil, ErrNotFound
+	}
+	sess.lastSeen = now
+	m.mu.Unlock()
+	return sess, nil
+}
+
+// Delete ends the session with the given ID.
+func (m *Manager) Delete(id string) {
+	m.mu.Lock()
+	sess, ok := m.sessions[id]
+	if ok {
+		m.remove(sess)
+	}
+	m.mu.Unlock()
+	if ok {
+		m.end(sess)
+	}
+}
+
+// Len returns the number of live sessions.
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// StartJanitor removes expired sessions every interval for the lifetime of the process.
+func (m *Manager) StartJanitor(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.mu.Lock()
+			removed := m.removeExpired(m.now())
+			m.mu.Unlock()
+			m.end(removed...)
+		}
+	}()
+}
+
+func (m *Manager) expired(sess *Session, now time.Time) bool {
+	if now.After(sess.ExpiresAt) {
+		return true
+	}
+	return m.idleTimeout > 0 && now.Sub(sess.lastSeen) > m.idleTimeout
+}
+
+// removeExpired must be called with m.mu held, the removed sessions are returned to be ended.
+func (m *Manager) removeExpired(now time.Time) []*Session {
+	var removed []*Session
+	for _, sess := range m.sessions {
+		if m.expired(sess, now) {
+			m.remove(sess)
+			removed = append(removed, sess)
+		}
+	}
+	return removed
+}
+
+// evictOldest must be called with m.mu held, the evicted session is returned to be ended.
+func (m *Manager) evictOldest() *Session {
+	var oldest *Session
+	for _, sess := range m.sessions {
+		if oldest == nil || sess.lastSeen.Before(oldest.lastSeen) {
+			oldest = sess
+		}
+	}
+	if oldest != nil {
+		m.remove(oldest)
+	}
+	return oldest
+}
+
+// remove must be called with m.mu held. The session must be ended with end after unlocking.
+func (m *Manager) remove(sess *Session) {
+	delete(m.sessions, sess.ID)
+}
+
+// end stops the removed sessions and runs the OnRemove callbacks, m.mu must not be held.
+func (m *Manager) end(sessions ...*Session) {
+	if len(sessions) == 0 {
+		return
+	}
+	m.mu.Lock()
+	callbacks := m.onRemove
+	m.mu.Unlock()
+	for _, sess := range sessions {
+		sess.close()
+		for _, fn := range callbacks {
+			fn(sess)
+		}
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SetContext stores the session in the request context.
+func SetContext(c *gin.Context, sess *Session) {
+	c.Set(contextKey, sess)
+}
+
+// FromContext returns the session of the current request.
+// It returns nil on routes that are not behind the auth middleware.
+func FromContext(c *gin.Context) *Session {
+	value, ok := c.Get(contextKey)
+	if !ok {
+		return nil
+	}
+	sess, _ := value.(*Session)
+	return sess
+}
@@ -0,0 +1,189 @@
+package session
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// clock is a manual clock for the manager.
+type clock struct{ now time.Time }
+
+func (c *clock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestManager(ttl, idleTimeout time.Duration, maxSessions int) (*Manager, *clock) {
+	c := &clock{now: time.Date(2024, 9, 1, 9, 0, 0, 0, time.UTC)}
+	m := NewManager(ttl, idleTimeout, maxSessions)
+	m.now = func() time.Time { return c.now }
+	return m, c
+}
+
+func create(t *testing.T, m *Manager, email string) *Session {
+	t.Helper()
+	sess, err := m.Create(&models.Person{Email: email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func TestManagerExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleTimeout time.Duration
+		// uses are the waits before each Get, the last Get decides
+		uses  []time.Duration
+		alive bool
+	}{
+		{"fresh", 10 * time.Minute, []time.Duration{0}, true},
+		{"used within the idle timeout", 10 * time.Minute, []time.Duration{9 * time.Minute, 9 * time.Minute, 9 * time.Minute}, true},
+		{"idle", 10 * time.Minute, []time.Duration{11 * time.Minute}, false},
+		{"idle after use", 10 * time.Minute, []time.Duration{5 * time.Minute, 11 * time.Minute}, false},
+		{"ttl reached while in use", 10 * time.Minute, []time.Duration{9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute}, false},
+		{"no idle timeout", 0, []time.Duration{59 * time.Minute}, true},
+		{"no idle timeout after ttl", 0, []time.Duration{61 * time.Minute}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, c := newTestManager(time.Hour, tt.idleTimeout, 0)
+			sess := create(t, m, "student")
+
+			var err error
+			for _, wait := range tt.uses {
+				c.advance(wait)
+				_, err = m.Get(sess.ID)
+			}
+			if alive := err == nil; alive != tt.alive {
+				t.Fatalf("Get() error = %v, want alive %v", err, tt.alive)
+			}
+			if !tt.alive {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get() error = %v, want ErrNotFound", err)
+				}
+				if m.Len() != 0 {
+					t.Errorf("Len() = %d, the expired session was not removed", m.Len())
+				}
+			}
+		})
+	}
+}
+
+func TestManagerEviction(t *testing.T) {
+	t.Run("least recently used", func(t *testing.T) {
+		m, c := newTestManager(time.Hour, 0, 2)
+		a := create(t, m, "a")
+		c.advance(time.Minute)
+		b := create(t, m, "b")
+		c.advance(time.Minute)
+		// a is used after b was created, so b is now the least recently used
+		if _, err := m.Get(a.ID); err != nil {
+			t.Fatal(err)
+		}
+		c.advance(time.Minute)
+		create(t, m, "c")
+
+		if _, err := m.Get(b.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(b) error = %v, want b evicted", err)
+		}
+		if _, err := m.Get(a.ID); err != nil {
+			t.Errorf("Get(a) error = %v, want a kept", err)
+		}
+		if m.Len() != 2 {
+			t.Errorf("Len() = %d, want 2", m.Len())
+		}
+	})
+
+	t.Run("expired sessions go first", func(t *testing.T) {
+		m, c := newTestManager(time.Hour, 10*time.Minute, 2)
+		a := create(t, m, "a")
+		c.advance(5 * time.Minute)
+		b := create(t, m, "b")
+		c.advance(6 * time.Minute)
+		// a is idle for 11 minutes, b for 6; only a has to go
+		create(t, m, "c")
+
+		if _, err := m.Get(a.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(a) error = %v, want a removed", err)
+		}
+		if _, err := m.Get(b.ID); err != nil {
+			t.Errorf("Get(b) error = %v, want b kept", err)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		m, _ := newTestManager(time.Hour, 0, 0)
+		for range 5 {
+			create(t, m, "a")
+		}
+		if m.Len() != 5 {
+			t.Errorf("Len() = %d, want 5", m.Len())
+		}
+	})
+}
+
+func TestManagerOnRemove(t *testing.T) {
+	m, c := newTestManager(time.Hour, 10*time.Minute, 2)
+	var removed []string
+	m.OnRemove(func(sess *Session) { removed = append(removed, sess.Person.GetEmail()) })
+	m.OnRemove(func(sess *Session) { removed = append(removed, "second "+sess.Person.GetEmail()) })
+
+	loggedOut := create(t, m, "logout")
+	m.Delete(loggedOut.ID)
+	m.Delete(loggedOut.ID)
+
+	idle := create(t, m, "idle")
+	c.advance(11 * time.Minute)
+	m.Get(idle.ID)
+
+	create(t, m, "evicted")
+	c.advance(time.Minute)
+	create(t, m, "kept")
+	c.advance(time.Minute)
+	create(t, m, "new")
+
+	want := []string{"logout", "second logout", "idle", "second idle", "evicted", "second evicted"}
+	if !slices.Equal(removed, want) {
+		t.Errorf("removed = %q, want %q", removed, want)
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"yilmaz21", "yilmaz21"},
+		{"Yilmaz21", "yilmaz21"},
+		{" yilmaz21@itu.edu.tr ", "yilmaz21"},
+		{"YILMAZ21@ITU.EDU.TR", "yilmaz21"},
+		{"yilmaz21@gmail.com", "yilmaz21@gmail.com"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeUsername(tt.email); got != tt.want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+// Callbacks run after the manager is unlocked, so they may use it and do slow work
+// without blocking other requests.
+func TestManagerOnRemoveUnlocked(t *testing.T) {
+	m, c := newTestManager(time.Hour, 10*time.Minute, 0)
+	var lens []int
+	m.OnRemove(func(*Session) { lens = append(lens, m.Len()) })
+
+	m.Delete(create(t, m, "logout").ID)
+
+	idle := create(t, m, "idle")
+	create(t, m, "active")
+	c.advance(11 * time.Minute)
+	m.Get(idle.ID)
+
+	if !slices.Equal(lens, []int{0, 1}) {
+		t.Errorf("Len() in callbacks = %v, want [0 1]", lens)
+	}
+}
@@ -0,0 +1,111 @@
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// forge signs any header and payload with the signer's secret.
+func forge(s *Signer, headerJSON string, payload any) string {
+	data, _ := json.Marshal(payload)
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(headerJSON)) + "." + base64.RawURLEncoding.EncodeToString(data)
+	return unsigned + "." + s.sign(unsigned)
+}
+
+func TestIssueParse(t *testing.T) {
+	s := NewSigner(secret, time.Hour)
+	signed, issued, err := s.Issue("student@itu.edu.tr", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims != issued {
+		t.Errorf("Parse() = %+v, want %+v", claims, issued)
+	}
+	if claims.Subject != "student@itu.edu.tr" || claims.SessionID != "session-1" || claims.ID == "" {
+		t.Errorf("Parse() = %+v", claims)
+	}
+	if got := claims.Expiry().Sub(time.Unix(claims.IssuedAt, 0)); got != time.Hour {
+		t.Errorf("lifetime = %s, want 1h", got)
+	}
+
+	other, _, err := s.Issue("student@itu.edu.tr", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherClaims, _ := s.Parse(other); otherClaims.ID == claims.ID {
+		t.Error("two tokens got the same jti")
+	}
+}
+
+func TestParse(t *testing.T) {
+	s := NewSigner(secret, time.Hour)
+	valid, _, err := s.Issue("student", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	now := time.Now()
+	claims := func(sid, jti string, exp time.Time) Claims {
+		return Claims{Subject: "student", SessionID: sid, ID: jti, IssuedAt: now.Unix(), ExpiresAt: exp.Unix()}
+	}
+	const hs256 = `{"alg":"HS256","typ":"JWT"}`
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", valid, nil},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), ErrInvalid},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","sid":"session-1","jti":"x","exp":9999999999}`)) + "." + parts[2], ErrInvalid},
+		{"signed with another secret", forge(NewSigner([]byte("another secret"), time.Hour), hs256, claims("session-1", "x", now.Add(time.Hour))), ErrInvalid},
+		{"no signature", parts[0] + "." + parts[1], ErrInvalid},
+		{"empty signature", parts[0] + "." + parts[1] + ".", ErrInvalid},
+		{"alg none", forge(s, `{"alg":"none","typ":"JWT"}`, claims("session-1", "x", now.Add(time.Hour))), ErrInvalid},
+		{"other header spelling", forge(s, `{"typ":"JWT","alg":"HS256"}`, claims("session-1", "x", now.Add(time.Hour))), ErrInvalid},
+		{"payload not json", forge(s, hs256, "not an object"), ErrInvalid},
+		{"missing sid", forge(s, hs256, claims("", "x", now.Add(time.Hour))), ErrInvalid},
+		{"missing jti", forge(s, hs256, claims("session-1", "", now.Add(time.Hour))), ErrInvalid},
+		{"expired", forge(s, hs256, claims("session-1", "x", now.Add(-time.Minute))), ErrExpired},
+		{"expired and missing sid", forge(s, hs256, claims("", "x", now.Add(-time.Minute))), ErrInvalid},
+		{"empty", "", ErrInvalid},
+		{"garbage", "a.b.c", ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Parse(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == ErrInvalid && got != (Claims{}) {
+				t.Errorf("Parse() returned claims %+v with ErrInvalid", got)
+			}
+		})
+	}
+}
+
+// An expired token still tells which session it belonged to, so the session can be ended.
+func TestParseExpiredReturnsClaims(t *testing.T) {
+	s := NewSigner(secret, -time.Minute)
+	signed, issued, err := s.Issue("student", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.Parse(signed)
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("Parse() error = %v, want ErrExpired", err)
+	}
+	if claims != issued {
+		t.Errorf("Parse() = %+v, want %+v", claims, issued)
+	}
+}
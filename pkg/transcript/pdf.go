@@ -0,0 +1,637 @@
+package transcript
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This is a small PDF text extractor, just enough for generated documents like
+// the transcript: it reads (compressed) objects and object streams, maps glyphs
+// through the ToUnicode CMaps of the fonts and orders the text by its position
+// on the page. It does not handle encryption or images.
+
+var (
+	objRe      = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	refRe      = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	namedRefRe = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	fontDictRe = regexp.MustCompile(`/Font\s*(<<(?:[^<>]|<<[^<>]*>>)*>>|\d+\s+\d+\s+R)`)
+	xobjDictRe = regexp.MustCompile(`/XObject\s*(<<(?:[^<>]|<<[^<>]*>>)*>>|\d+\s+\d+\s+R)`)
+	toUniRe    = regexp.MustCompile(`/ToUnicode\s+(\d+)\s+\d+\s+R`)
+	contentsRe = regexp.MustCompile(`/Contents\s*(\[[^\]]*\]|\d+\s+\d+\s+R)`)
+	kidsRe     = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	pageTypeRe = regexp.MustCompile(`/Type\s*/Page\b`)
+	pagesRe    = regexp.MustCompile(`/Type\s*/Pages\b`)
+	firstRe    = regexp.MustCompile(`/First\s+(\d+)`)
+	countRe    = regexp.MustCompile(`/N\s+(\d+)`)
+)
+
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+type pdfDocument struct {
+	objects map[int]pdfObject
+	order   []int
+	fonts   map[string]*cmap
+	xobjs   map[string]int
+}
+
+// pdfText returns the text of every page, one line per text line of the page.
+func pdfText(data []byte) string {
+	doc := &pdfDocument{objects: make(map[int]pdfObject), fonts: make(map[string]*cmap), xobjs: make(map[string]int)}
+	doc.readObjects(data)
+	doc.readResources()
+
+	var text strings.Builder
+	for _, page := range doc.pages() {
+		var runs []textRun
+		for _, ref := range refRe.FindAllStringSubmatch(contentsRe.FindStringSubmatch(doc.objects[page].dict)[1], -1) {
+			runs = doc.interpret(doc.objects[atoi(ref[1])].stream, identity, runs, 0)
+		}
+		for _, line := range layoutLines(runs) {
+			text.WriteString(line)
+			text.WriteByte('\n')
+		}
+	}
+	return text.String()
+}
+
+func (doc *pdfDocument) readObjects(data []byte) {
+	matches := objRe.FindAllSubmatchIndex(data, -1)
+	for i, match := range matches {
+		end := len(data)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		body := data[match[1]:end]
+		if idx := bytes.Index(body, []byte("endobj")); idx >= 0 {
+			body = body[:idx]
+		}
+
+		obj := pdfObject{dict: string(body)}
+		if idx := bytes.Index(body, []byte("stream")); idx >= 0 {
+			obj.dict = string(body[:idx])
+			stream := body[idx+len("stream"):]
+			stream = bytes.TrimPrefix(stream, []byte("\r"))
+			stream = bytes.TrimPrefix(stream, []byte("\n"))
+			if end := bytes.LastIndex(stream, []byte("endstream")); end >= 0 {
+				stream = stream[:end]
+			}
+			if strings.Contains(obj.dict, "/FlateDecode") {
+				// A truncated stream still yields the text inflated so far
+				r, err := zlib.NewReader(bytes.NewReader(stream))
+				if err == nil {
+					stream, _ = io.ReadAll(r)
+				}
+			}
+			obj.stream = stream
+		}
+
+		num := atoi(string(data[match[2]:match[3]]))
+		doc.add(num, obj)
+		if strings.Contains(obj.dict, "/ObjStm") {
+			doc.readObjectStream(obj)
+		}
+	}
+}
+
+// readObjectStream unpacks the objects compressed into an object stream.
+func (doc *pdfDocument) readObjectStream(obj pdfObject) {
+	first, n := firstRe.FindStringSubmatch(obj.dict), countRe.FindStringSubmatch(obj.dict)
+	if first == nil || n == nil || atoi(first[1]) < 0 || atoi(first[1]) > len(obj.stream) {
+		return
+	}
+	offset := atoi(first[1])
+	header := strings.Fields(string(obj.stream[:offset]))
+	for i := 0; i+1 < len(header) && i/2 < atoi(n[1]); i += 2 {
+		start := offset + atoi(header[i+1])
+		end := len(obj.stream)
+		if i+3 < len(header) {
+			end = offset + atoi(header[i+3])
+		}
+		if start < offset || start > end || end > len(obj.stream) {
+			return
+		}
+		doc.add(atoi(header[i]), pdfObject{dict: string(obj.stream[start:end])})
+	}
+}
+
+func (doc *pdfDocument) add(num int, obj pdfObject) {
+	if _, ok := doc.objects[num]; !ok {
+		doc.order = append(doc.order, num)
+	}
+	doc.objects[num] = obj
+}
+
+// readResources collects the fonts and form XObjects of all pages by resource name.
+// Generated documents use the same name for the same font on every page.
+func (doc *pdfDocument) readResources() {
+	for _, num := range doc.order {
+		dict := doc.objects[num].dict
+		for _, match := range fontDictRe.FindAllStringSubmatch(dict, -1) {
+			for _, font := range namedRefRe.FindAllStringSubmatch(doc.resolveDict(match[1]), -1) {
+				if _, ok := doc.fonts[font[1]]; ok {
+					continue
+				}
+				if toUni := toUniRe.FindStringSubmatch(doc.objects[atoi(font[2])].dict); toUni != nil {
+					doc.fonts[font[1]] = parseCMap(doc.objects[atoi(toUni[1])].stream)
+				} else {
+					doc.fonts[font[1]] = nil
+				}
+			}
+		}
+		for _, match := range xobjDictRe.FindAllStringSubmatch(dict, -1) {
+			for _, xobj := range namedRefRe.FindAllStringSubmatch(doc.resolveDict(match[1]), -1) {
+				doc.xobjs[xobj[1]] = atoi(xobj[2])
+			}
+		}
+	}
+}
+
+// resolveDict returns the dictionary an indirect reference points to.
+func (doc *pdfDocument) resolveDict(value string) string {
+	if ref := refRe.FindStringSubmatch(value); ref != nil && !strings.HasPrefix(value, "<<") {
+		return doc.objects[atoi(ref[1])].dict
+	}
+	return value
+}
+
+// pages returns the page objects in reading order.
+func (doc *pdfDocument) pages() []int {
+	var pages []int
+	var walk func(num int, depth int)
+	walk = func(num int, depth int) {
+		dict := doc.objects[num].dict
+		switch {
+		case depth > 32:
+		case pagesRe.MatchString(dict):
+			if kids := kidsRe.FindStringSubmatch(dict); kids != nil {
+				for _, ref := range refRe.FindAllStringSubmatch(kids[1], -1) {
+					walk(atoi(ref[1]), depth+1)
+				}
+			}
+		case pageTypeRe.MatchString(dict) && contentsRe.MatchString(dict):
+			pages = append(pages, num)
+		}
+	}
+	for _, num := range doc.order {
+		dict := doc.objects[num].dict
+		if pagesRe.MatchString(dict) && !strings.Contains(dict, "/Parent") {
+			walk(num, 0)
+			return pages
+		}
+	}
+	// No page tree, fall back to the object order
+	for _, num := range doc.order {
+		if dict := doc.objects[num].dict; pageTypeRe.MatchString(dict) && contentsRe.MatchString(dict) {
+			pages = append(pages, num)
+		}
+	}
+	return pages
+}
+
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+type textRun struct {
+	x, y, size float64
+	text       string
+}
+
+// interpret runs a content stream and appends the text it shows.
+func (doc *pdfDocument) interpret(content []byte, ctm matrix, runs []textRun, depth int) []textRun {
+	var (
+		operands []pdfToken
+		stack    []matrix
+		tm, tlm  = identity, identity
+		font     *cmap
+		size     float64
+		leading  float64
+	)
+	nextLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.mul(tlm)
+		tm = tlm
+	}
+	show := func(text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		m := tm.mul(ctm)
+		scale := m[3]
+		if scale < 0 {
+			scale = -scale
+		}
+		runs = append(runs, textRun{x: m[4], y: m[5], size: size * scale, text: text})
+	}
+
+	lex := &pdfLexer{data: content}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.kind != tokOperator {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.value {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			}
+		case "cm":
+			if m, ok := matrixOperands(operands); ok {
+				ctm = m.mul(ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) >= 2 {
+				font = doc.fonts[operands[len(operands)-2].value]
+				size = operands[len(operands)-1].number()
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				leading = operands[len(operands)-1].number()
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty := operands[len(operands)-1].number()
+				nextLine(operands[len(operands)-2].number(), ty)
+				if tok.value == "TD" {
+					leading = -ty
+				}
+			}
+		case "Tm":
+			if m, ok := matrixOperands(operands); ok {
+				tm, tlm = m, m
+			}
+		case "T*":
+			nextLine(0, -leading)
+		case "Tj", "'", "\"":
+			if tok.value != "Tj" {
+				nextLine(0, -leading)
+			}
+			if len(operands) >= 1 {
+				show(font.decode(operands[len(operands)-1].raw))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				var text strings.Builder
+				for _, item := range operands[len(operands)-1].items {
+					if item.kind == tokString {
+						text.WriteString(font.decode(item.raw))
+					} else if item.number() < -200 {
+						// A wide negative kerning is how many generators write a space
+						text.WriteByte(' ')
+					}
+				}
+				show(text.String())
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < 8 {
+				if num, ok := doc.xobjs[operands[len(operands)-1].value]; ok {
+					if xobj := doc.objects[num]; strings.Contains(xobj.dict, "/Form") {
+						runs = doc.interpret(xobj.stream, ctm, runs, depth+1)
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return runs
+}
+
+func matrixOperands(operands []pdfToken) (matrix, bool) {
+	if len(operands) < 6 {
+		return matrix{}, false
+	}
+	var m matrix
+	for i, op := range operands[len(operands)-6:] {
+		m[i] = op.number()
+	}
+	return m, true
+}
+
+// layoutLines groups the text runs into lines from the top of the page down.
+func layoutLines(runs []textRun) []string {
+	sort.SliceStable(runs, func(i, j int) bool {
+		if diff := runs[i].y - runs[j].y; diff > 2 || diff < -2 {
+			return runs[i].y > runs[j].y
+		}
+		return runs[i].x < runs[j].x
+	})
+
+	var lines []string
+	var line strings.Builder
+	var lineY, end float64
+	for i, run := range runs {
+		if i > 0 && (lineY-run.y > 2 || run.y-lineY > 2) {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() == 0 {
+			lineY = run.y
+		} else if run.x-end > run.size*0.15 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(run.text)
+		// Glyph widths are not known, assume an average glyph is half as wide as it is high
+		end = run.x + float64(len([]rune(run.text)))*run.size*0.5
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// cmap maps character codes to text, it comes from the ToUnicode stream of a font.
+type cmap struct {
+	codeLen int
+	chars   map[uint32]string
+}
+
+var (
+	codespaceRe = regexp.MustCompile(`begincodespacerange\s*<([0-9A-Fa-f]+)>`)
+	bfcharRe    = regexp.MustCompile(`(?s)beginbfchar(.*?)endbfchar`)
+	bfrangeRe   = regexp.MustCompile(`(?s)beginbfrange(.*?)endbfrange`)
+	hexPairRe   = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]*)>`)
+	rangeRe     = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(<[0-9A-Fa-f]*>|\[[^\]]*\])`)
+	hexRe       = regexp.MustCompile(`<([0-9A-Fa-f]*)>`)
+)
+
+func parseCMap(data []byte) *cmap {
+	text := string(data)
+	c := &cmap{codeLen: 1, chars: make(map[uint32]string)}
+	if match := codespaceRe.FindStringSubmatch(text); match != nil {
+		c.codeLen = max(1, len(match[1])/2)
+	}
+	for _, block := range bfcharRe.FindAllStringSubmatch(text, -1) {
+		for _, pair := range hexPairRe.FindAllStringSubmatch(block[1], -1) {
+			c.chars[hexCode(pair[1])] = utf16Hex(pair[2])
+		}
+	}
+	for _, block := range bfrangeRe.FindAllStringSubmatch(text, -1) {
+		for _, r := range rangeRe.FindAllStringSubmatch(block[1], -1) {
+			lo, hi := hexCode(r[1]), hexCode(r[2])
+			if hi < lo || hi-lo > 0xffff {
+				continue
+			}
+			if strings.HasPrefix(r[3], "[") {
+				for i, dst := range hexRe.FindAllStringSubmatch(r[3], -1) {
+					c.chars[lo+uint32(i)] = utf16Hex(dst[1])
+				}
+				continue
+			}
+			dst := utf16.Decode(utf16Units(strings.Trim(r[3], "<>")))
+			for code := lo; code <= hi && len(dst) > 0; code++ {
+				c.chars[code] = string(dst)
+				dst[len(dst)-1]++
+			}
+		}
+	}
+	return c
+}
+
+// decode maps the bytes of a string operand to text. Fonts without a ToUnicode
+// CMap are assumed to use a Latin encoding.
+func (c *cmap) decode(raw []byte) string {
+	if c == nil {
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	var text strings.Builder
+	for i := 0; i+c.codeLen <= len(raw); i += c.codeLen {
+		var code uint32
+		for _, b := range raw[i : i+c.codeLen] {
+			code = code<<8 | uint32(b)
+		}
+		if s, ok := c.chars[code]; ok {
+			text.WriteString(s)
+		} else if c.codeLen == 1 {
+			text.WriteByte(raw[i])
+		}
+	}
+	return text.String()
+}
+
+func hexCode(s string) uint32 {
+	code, _ := strconv.ParseUint(s, 16, 32)
+	return uint32(code)
+}
+
+func utf16Units(s string) []uint16 {
+	var units []uint16
+	for i := 0; i+4 <= len(s); i += 4 {
+		units = append(units, uint16(hexCode(s[i:i+4])))
+	}
+	return units
+}
+
+func utf16Hex(s string) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+type tokenKind int
+
+const (
+	tokNumber tokenKind = iota
+	tokName
+	tokString
+	tokArray
+	tokOperator
+	tokOther
+)
+
+type pdfToken struct {
+	kind  tokenKind
+	value string
+	raw   []byte     // decoded bytes of a string
+	items []pdfToken // elements of an array
+}
+
+func (t pdfToken) number() float64 {
+	n, _ := strconv.ParseFloat(t.value, 64)
+	return n
+}
+
+// pdfLexer splits a content stream into tokens.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isDelimiter(b byte) bool {
+	return strings.IndexByte("()<>[]{}/%", b) >= 0
+}
+
+func isSpace(b byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00", b) >= 0
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		switch {
+		case isSpace(b):
+			l.pos++
+		case b == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case b == '(':
+			l.pos++
+			return pdfToken{kind: tokString, raw: l.literal()}, true
+		case b == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+			return pdfToken{kind: tokOther, value: "<<"}, true
+		case b == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{kind: tokOther, value: ">>"}, true
+		case b == '<':
+			end := bytes.IndexByte(l.data[l.pos:], '>')
+			if end < 0 {
+				end = len(l.data) - l.pos
+			}
+			hex := strings.Join(strings.Fields(string(l.data[l.pos+1:l.pos+end])), "")
+			l.pos += end + 1
+			if len(hex)%2 == 1 {
+				hex += "0"
+			}
+			raw := make([]byte, len(hex)/2)
+			for i := range raw {
+				raw[i] = byte(hexCode(hex[2*i : 2*i+2]))
+			}
+			return pdfToken{kind: tokString, raw: raw}, true
+		case b == '[':
+			l.pos++
+			array := pdfToken{kind: tokArray}
+			for {
+				tok, ok := l.next()
+				if !ok || (tok.kind == tokOther && tok.value == "]") {
+					break
+				}
+				array.items = append(array.items, tok)
+			}
+			return array, true
+		case b == ']' || b == '{' || b == '}' || b == ')' || b == '>':
+			l.pos++
+			return pdfToken{kind: tokOther, value: string(b)}, true
+		case b == '/':
+			start := l.pos + 1
+			l.pos++
+			for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+				l.pos++
+			}
+			return pdfToken{kind: tokName, value: string(l.data[start:l.pos])}, true
+		default:
+			start := l.pos
+			for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+				l.pos++
+			}
+			word := string(l.data[start:l.pos])
+			if _, err := strconv.ParseFloat(word, 64); err == nil {
+				return pdfToken{kind: tokNumber, value: word}, true
+			}
+			if word == "BI" {
+				l.skipInlineImage()
+			}
+			return pdfToken{kind: tokOperator, value: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// literal reads a (string) whose opening parenthesis has been consumed.
+func (l *pdfLexer) literal() []byte {
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b = '\n'
+			case 'r':
+				b = '\r'
+			case 't':
+				b = '\t'
+			case 'b':
+				b = '\b'
+			case 'f':
+				b = '\f'
+			case '\r', '\n':
+				// Line continuation
+				if e == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					code := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						code = code*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = byte(code)
+				} else {
+					b = e
+				}
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// skipInlineImage skips the binary data of an inline image up to its EI operator.
+func (l *pdfLexer) skipInlineImage() {
+	if end := bytes.Index(l.data[l.pos:], []byte("EI")); end >= 0 {
+		l.pos += end + 2
+	} else {
+		l.pos = len(l.data)
+	}
+}
@@ -0,0 +1,122 @@
+package transcript
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// buildPDF numbers the objects from 1 and writes them with an xref table, like pdf generators do.
+func buildPDF(objects ...string) []byte {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+func stream(content string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+}
+
+func flateStream(content string) string {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte(content))
+	w.Close()
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String())
+}
+
+const (
+	catalogObj = "<< /Type /Catalog /Pages 2 0 R >>"
+	pagesObj   = "<< /Type /Pages /Kids [3 0 R] /Count 1 >>"
+	pageObj    = "<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>"
+	fontObj    = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+	pageText   = "BT /F1 9 Tf 1 0 0 1 50 800 Tm (2023-2024 Fall) Tj ET\n" +
+		"BT /F1 9 Tf 1 0 0 1 50 788 Tm (BLG 101E) Tj ET\n" +
+		"BT /F1 9 Tf 1 0 0 1 110 788 Tm [(Intro) -250 (to) -250 (Computing)] TJ ET\n" +
+		"BT /F1 9 Tf 50 770 Td (Term GPA: 3.50) Tj T* ET\n"
+)
+
+func TestPDFText(t *testing.T) {
+	want := "2023-2024 Fall\nBLG 101E Intro to Computing\nTerm GPA: 3.50\n"
+
+	objStm := func() string {
+		// Objects 2 and 3 packed into an object stream, as PDF 1.5 writers do
+		objects := pagesObj + " " + pageObj
+		header := fmt.Sprintf("2 0 3 %d ", len(pagesObj)+1)
+		return fmt.Sprintf("<< /Type /ObjStm /N 2 /First %d /Length %d >>\nstream\n%s%s\nendstream",
+			len(header), len(header)+len(objects), header, objects)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"plain stream", buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText)), want},
+		{"compressed stream", buildPDF(catalogObj, pagesObj, pageObj, fontObj, flateStream(pageText)), want},
+		{"contents array", buildPDF(catalogObj, pagesObj,
+			strings.Replace(pageObj, "/Contents 5 0 R", "/Contents [5 0 R 6 0 R]", 1), fontObj,
+			stream("BT /F1 9 Tf 1 0 0 1 50 800 Tm (first) Tj ET"),
+			stream("BT /F1 9 Tf 1 0 0 1 50 700 Tm (second) Tj ET")), "first\nsecond\n"},
+		{"object stream", buildPDF(catalogObj, "null", "null", fontObj, flateStream(pageText), objStm()), want},
+		{"page without contents", buildPDF(catalogObj, pagesObj,
+			"<< /Type /Page /Parent 2 0 R /Resources << >> >>"), ""},
+		{"malformed xref", bytes.Replace(buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText)),
+			[]byte("startxref\n"), []byte("startxref\n99999999"), 1), want},
+		{"no xref", bytes.Split(buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText)), []byte("xref"))[0], want},
+		{"broken object stream offsets", buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText),
+			"<< /Type /ObjStm /N 2 /First 12 /Length 20 >>\nstream\n7 -90 8 999 \n<< >>\nendstream"), want},
+		{"negative object stream offset", buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText),
+			"<< /Type /ObjStm /N 2 /First 10 /Length 18 >>\nstream\n7 -90 8 0 \n<< >>\nendstream"), want},
+		{"negative object stream first", buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText),
+			"<< /Type /ObjStm /N 1 /First -5 /Length 10 >>\nstream\n7 0 << >>\nendstream"), want},
+		{"corrupt compressed stream", buildPDF(catalogObj, pagesObj, pageObj, fontObj,
+			"<< /Length 8 /Filter /FlateDecode >>\nstream\nnot zlib\nendstream"), ""},
+		{"empty", nil, ""},
+		{"not a pdf", []byte("<html>nope</html>"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pdfText(tt.data); got != tt.want {
+				t.Errorf("pdfText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPDFTextTruncated cuts the documents at every length, a download cut short
+// must lose text, not panic.
+func TestPDFTextTruncated(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/fakekepler.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents := [][]byte{
+		fixture,
+		buildPDF(catalogObj, pagesObj, pageObj, fontObj, stream(pageText)),
+	}
+	for _, data := range documents {
+		for n := range data {
+			pdfText(data[:n])
+		}
+	}
+
+	// Cut before the first term, nothing can be parsed
+	if _, err := Parse(Text(fixture[:len(fixture)/3], ContentTypePDF)); err != ErrNoTerms {
+		t.Errorf("Parse(truncated) error = %v, want ErrNoTerms", err)
+	}
+}
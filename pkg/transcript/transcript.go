@@ -0,0 +1,211 @@
+// Package transcript reads the transcript document Kepler produces and turns it
+// into a models.Transcript.
+package transcript
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeHTML = "text/html; charset=utf-8"
+)
+
+var (
+	// ErrUnknownDocument is returned when the response holds neither a PDF nor an HTML document.
+	ErrUnknownDocument = errors.New("transcript document format not recognized")
+	// ErrNoTerms is returned when no term could be read from the document.
+	ErrNoTerms = errors.New("no terms found in transcript")
+)
+
+// Document extracts the transcript document from a Kepler response. The preview
+// endpoint answers with the document itself or with JSON carrying it base64 encoded.
+func Document(body []byte) (data []byte, contentType string, err error) {
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("%PDF")):
+		return body, ContentTypePDF, nil
+	case isHTML(trimmed):
+		return body, ContentTypeHTML, nil
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")), bytes.HasPrefix(trimmed, []byte(`"`)):
+		var wrapper any
+		if err := json.Unmarshal(trimmed, &wrapper); err != nil {
+			return nil, "", err
+		}
+		if data, contentType, ok := findDocument(wrapper); ok {
+			return data, contentType, nil
+		}
+	}
+	return nil, "", ErrUnknownDocument
+}
+
+// findDocument looks through a JSON value for a string holding the document.
+func findDocument(value any) ([]byte, string, bool) {
+	switch value := value.(type) {
+	case string:
+		if isHTML([]byte(strings.TrimSpace(value))) {
+			return []byte(value), ContentTypeHTML, true
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			if bytes.HasPrefix(decoded, []byte("%PDF")) {
+				return decoded, ContentTypePDF, true
+			}
+			if isHTML(bytes.TrimSpace(decoded)) {
+				return decoded, ContentTypeHTML, true
+			}
+		}
+	case map[string]any:
+		for _, item := range value {
+			if data, contentType, ok := findDocument(item); ok {
+				return data, contentType, true
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if data, contentType, ok := findDocument(item); ok {
+				return data, contentType, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+func isHTML(data []byte) bool {
+	prefix := strings.ToLower(string(data[:min(len(data), 64)]))
+	return strings.HasPrefix(prefix, "<!doctype html") || strings.HasPrefix(prefix, "<html")
+}
+
+// Text returns the plain text of a document, one line per text line.
+func Text(data []byte, contentType string) string {
+	if contentType == ContentTypePDF {
+		return pdfText(data)
+	}
+	return htmlText(data)
+}
+
+var (
+	breakRe = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h\d|table)>`)
+	cellRe  = regexp.MustCompile(`(?i)</t[dh]>`)
+	tagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+	dropRe  = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+)
+
+func htmlText(data []byte) string {
+	text := dropRe.ReplaceAllString(string(data), "")
+	text = breakRe.ReplaceAllString(text, "\n")
+	text = cellRe.ReplaceAllString(text, " ")
+	text = html.UnescapeString(tagRe.ReplaceAllString(text, ""))
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	// "2023-2024 Fall", "Fall 2023-2024", "2023-2024 Güz Dönemi"
+	termRe = regexp.MustCompile(`(?i)^(?:(\d{4}\s*-\s*\d{4})\s+(fall|spring|summer|güz|bahar|yaz)|(fall|spring|summer|güz|bahar|yaz)\s+(?:term\s+|semester\s+)?(\d{4}\s*-\s*\d{4}))\b`)
+	// "BLG 101E Introduction to Computing 3 5 AA", the ECTS column and the grade are optional
+	courseRe = regexp.MustCompile(`^([A-ZÇĞİÖŞÜ]{2,4})\s*(\d{3}[A-Z]?)\s+(.*?)\s*(\d+(?:[.,]\d+)?)(?:\s+(\d+(?:[.,]\d+)?))?(?:\s+(AA|BA\+?|BB\+?|CB\+?|CC\+?|DC\+?|DD\+?|FF|VF|BL|BZ|EX|NA|P|F|W|I))?$`)
+	// "Cumulative GPA: 3.12", "CGPA 3.12", "GANO: 3,12"
+	cumulativeRe = regexp.MustCompile(`(?i)(?:cumulative\s+(?:gpa|average)|general\s+(?:gpa|average)|overall\s+gpa|cgpa|gano|genel\s+not\s+ortalaması)\s*[:=]?\s*(\d[.,]\d+)`)
+	// "Term GPA: 3.50", "Semester GPA 3.50", "YANO: 3,50", a lone "GPA: 3.50" inside a term
+	termGPARe = regexp.MustCompile(`(?i)(?:(?:term|semester)\s+(?:gpa|average)|yano|dönem\s+not\s+ortalaması|(?:^|[^a-z])gpa)\s*[:=]?\s*(\d[.,]\d+)`)
+)
+
+// Parse reads a transcript from the text of the document.
+func Parse(text string) (*models.Transcript, error) {
+	transcript := &models.Transcript{Terms: []models.TranscriptTerm{}}
+	var term *models.TranscriptTerm
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+
+		if match := termRe.FindStringSubmatch(line); match != nil {
+			transcript.Terms = append(transcript.Terms, models.TranscriptTerm{
+				Name:    termName(match),
+				Courses: []models.TranscriptCourse{},
+			})
+			term = &transcript.Terms[len(transcript.Terms)-1]
+			continue
+		}
+
+		// GPA lines may carry both averages, the cumulative one is cut out first
+		cumulative := cumulativeRe.FindStringSubmatch(line)
+		rest := cumulativeRe.ReplaceAllString(line, "")
+		if cumulative != nil {
+			gpa := parseNumber(cumulative[1])
+			transcript.CumulativeGPA = gpa
+			if term != nil {
+				term.CumulativeGPA = gpa
+			}
+		}
+		if match := termGPARe.FindStringSubmatch(rest); match != nil && term != nil {
+			term.TermGPA = parseNumber(match[1])
+		}
+		if cumulative != nil || termGPARe.MatchString(rest) {
+			continue
+		}
+
+		if match := courseRe.FindStringSubmatch(line); match != nil && term != nil {
+			course := models.TranscriptCourse{
+				Code:    match[1] + " " + match[2],
+				Title:   match[3],
+				Credits: parseNumber(match[4]),
+				Grade:   match[6],
+			}
+			if match[5] != "" {
+				course.ECTS = parseNumber(match[5])
+			}
+			term.Courses = append(term.Courses, course)
+			term.Credits += course.Credits
+		}
+	}
+
+	if len(transcript.Terms) == 0 {
+		return nil, ErrNoTerms
+	}
+	for _, term := range transcript.Terms {
+		transcript.TotalCredits += term.Credits
+	}
+	return transcript, nil
+}
+
+// termName normalizes a term header to "2023-2024 Fall".
+func termName(match []string) string {
+	years, season := match[1], match[2]
+	if years == "" {
+		years, season = match[4], match[3]
+	}
+	years = strings.Join(strings.Fields(strings.ReplaceAll(years, "-", " ")), "-")
+	switch strings.ToLower(season) {
+	case "güz":
+		season = "Fall"
+	case "bahar":
+		season = "Spring"
+	case "yaz":
+		season = "Summer"
+	default:
+		season = strings.ToUpper(season[:1]) + strings.ToLower(season[1:])
+	}
+	return years + " " + season
+}
+
+func parseNumber(s string) float64 {
+	n, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	return n
+}
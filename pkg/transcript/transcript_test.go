@@ -0,0 +1,151 @@
+package transcript
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ITU-BeeHub/BeeHub-backend/pkg/models"
+)
+
+// testdata/fakekepler.pdf is the transcript preview cmd/fakekepler serves for its default student.
+func TestFakeKeplerTranscript(t *testing.T) {
+	body, err := os.ReadFile("testdata/fakekepler.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, contentType, err := Document(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != ContentTypePDF {
+		t.Fatalf("content type = %q, want %q", contentType, ContentTypePDF)
+	}
+
+	got, err := Parse(Text(data, contentType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.Transcript{
+		Terms: []models.TranscriptTerm{
+			{
+				Name: "2022-2023 Fall",
+				Courses: []models.TranscriptCourse{
+					{Code: "BLG 101E", Title: "Introduction to Information Systems", Credits: 3, ECTS: 5, Grade: "AA"},
+					{Code: "MAT 103E", Title: "Mathematics I", Credits: 4, ECTS: 6, Grade: "BB"},
+					{Code: "FIZ 101E", Title: "Physics I", Credits: 4, ECTS: 6, Grade: "CB"},
+				},
+				Credits:       11,
+				TermGPA:       3.09,
+				CumulativeGPA: 3.09,
+			},
+			{
+				Name: "2022-2023 Spring",
+				Courses: []models.TranscriptCourse{
+					{Code: "BLG 102E", Title: "Introduction to Scientific and Engineering Computing (C)", Credits: 3, ECTS: 5, Grade: "BA"},
+					{Code: "MAT 104E", Title: "Mathematics II", Credits: 4, ECTS: 6, Grade: "CC"},
+				},
+				Credits:       7,
+				TermGPA:       2.93,
+				CumulativeGPA: 3.02,
+			},
+		},
+		TotalCredits:  18,
+		CumulativeGPA: 3.02,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    *models.Transcript
+		wantErr error
+	}{
+		{
+			name: "turkish headers and averages",
+			text: "2023-2024 Güz Dönemi\nMAT 103 Matematik I 4 6 BA\nYANO: 3,50 GANO: 3,25\n",
+			want: &models.Transcript{
+				Terms: []models.TranscriptTerm{{
+					Name:          "2023-2024 Fall",
+					Courses:       []models.TranscriptCourse{{Code: "MAT 103", Title: "Matematik I", Credits: 4, ECTS: 6, Grade: "BA"}},
+					Credits:       4,
+					TermGPA:       3.5,
+					CumulativeGPA: 3.25,
+				}},
+				TotalCredits:  4,
+				CumulativeGPA: 3.25,
+			},
+		},
+		{
+			name: "season first, no ECTS and no grade yet",
+			text: "Name: Student\nSpring Semester 2023 - 2024\nBLG 312E Computer Operations 3\n",
+			want: &models.Transcript{
+				Terms: []models.TranscriptTerm{{
+					Name:    "2023-2024 Spring",
+					Courses: []models.TranscriptCourse{{Code: "BLG 312E", Title: "Computer Operations", Credits: 3}},
+					Credits: 3,
+				}},
+				TotalCredits: 3,
+			},
+		},
+		{
+			name:    "courses before any term",
+			text:    "BLG 101E Intro 3 5 AA\nCumulative GPA: 3.00\n",
+			wantErr: ErrNoTerms,
+		},
+		{
+			name:    "empty",
+			text:    "",
+			wantErr: ErrNoTerms,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n%%EOF\n")
+	page := []byte("<!DOCTYPE html><html><body>2023-2024 Fall</body></html>")
+
+	tests := []struct {
+		name            string
+		body            []byte
+		wantData        []byte
+		wantContentType string
+		wantErr         bool
+	}{
+		{"pdf", pdf, pdf, ContentTypePDF, false},
+		{"html", page, page, ContentTypeHTML, false},
+		{"base64 pdf in json", []byte(`{"data":{"file":"` + base64.StdEncoding.EncodeToString(pdf) + `"}}`), pdf, ContentTypePDF, false},
+		{"html in json", []byte(`["` + string(page) + `"]`), page, ContentTypeHTML, false},
+		{"json without a document", []byte(`{"error":"none"}`), nil, "", true},
+		{"broken json", []byte(`{"data":`), nil, "", true},
+		{"empty", nil, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, contentType, err := Document(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Document() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(data) != string(tt.wantData) || contentType != tt.wantContentType {
+				t.Errorf("Document() = %q, %q, want %q, %q", data, contentType, tt.wantData, tt.wantContentType)
+			}
+		})
+	}
+}
@@ -2,11 +2,12 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	godotenv "github.com/joho/godotenv"
 )
@@ -62,184 +63,114 @@ func LoadEnvVariables() {
 	}
 }
 
-// GetUserSchedules reads the schedules.json file and returns the schedules of the user. (All schedules are returned)
-func GetUserSchedules() (ScheduleList, error) {
+// Returns the base path of the project.
+func getProjectBasePath() (string, error) {
+	basePath, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return basePath, nil
+}
 
-	path, err := getProjectBasePath()
+// legacySchedulesFile is where every schedule was kept before each user got their own file.
+const legacySchedulesFile = "schedules.json"
+
+// GetSchedulesOf reads the schedules of a user from schedules/<user>.json, a user without the file has none.
+func GetSchedulesOf(user string) (ScheduleList, error) {
+	path, err := userSchedulesPath(user)
 	if err != nil {
-		log.Fatal("Error getting project base path")
 		return ScheduleList{}, err
 	}
 
-	// open schedules.json
-	file, err := os.Open(filepath.Join(path, "schedules.json"))
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Create the file if it doesn't exist
-			file, err = os.Create(filepath.Join(path, "schedules.json"))
-			if err != nil {
-				log.Fatal("Error creating schedules.json")
-				return ScheduleList{}, err
-			}
-
-			// Write an empty JSON object to the file
-			_, err = file.Write([]byte("{\"schedules\":[]}"))
-			if err != nil {
-				log.Fatal("Error writing schedules.json")
-				return ScheduleList{}, err
-			}
-
-			// Close file after writing and reopen it so that the file pointer is at the beginning
-			// yes, this is a hack but it works
-			file.Close()
-			file, err = os.Open(filepath.Join(path, "schedules.json"))
-			if err != nil {
-				log.Fatal("Error opening schedules.json")
-				return ScheduleList{}, err
-			}
-
-		} else {
-			log.Fatal("Error opening schedules.json")
-			return ScheduleList{}, err
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if adopted, adoptErr := adoptLegacySchedules(path); adoptErr != nil {
+			return ScheduleList{}, adoptErr
+		} else if adopted {
+			log.Printf("Moved the shared %s to the schedules of %s", legacySchedulesFile, user)
+			return GetSchedulesOf(user)
 		}
+		return ScheduleList{Schedules: []Schedule{}}, nil
 	}
-	defer file.Close()
-
-	// Read the contents of the file
-	data, err := io.ReadAll(file)
 	if err != nil {
-		log.Fatal("Error reading schedules.json")
 		return ScheduleList{}, err
 	}
 
-	// Parse the JSON data into a ScheduleList struct
 	var scheduleList ScheduleList
-	err = json.Unmarshal(data, &scheduleList)
-	if err != nil {
-		log.Fatal("Error parsing schedules.json")
-		return ScheduleList{}, err
+	if err := json.Unmarshal(data, &scheduleList); err != nil {
+		return ScheduleList{}, fmt.Errorf("parsing %s: %w", path, err)
 	}
-
 	return scheduleList, nil
 }
 
-// GetUserSchedule reads the schedules.json file and returns the schedule with the given name.
-func GetUserSchedule(schedule_name string) (Schedule, error) {
-	scheduleList, err := GetUserSchedules()
+// SaveSchedulesOf writes the schedules of a user to schedules/<user>.json. (Overwrites the file)
+func SaveSchedulesOf(user string, scheduleList ScheduleList) error {
+	path, err := userSchedulesPath(user)
 	if err != nil {
-		return Schedule{}, err
-	}
-
-	// Find the schedule with the given name
-	var user_schedule Schedule
-	for _, schedule := range scheduleList.Schedules {
-		if schedule.Name == schedule_name {
-			user_schedule = schedule
-			break
-		}
-	}
-
-	// if the schedule is not found, return an error
-	if user_schedule.Name == "" {
-		return Schedule{}, fmt.Errorf("schedule not found")
-	}
-
-	return user_schedule, nil
-}
-
-// SaveUserSchedule saves the given schedule to the schedules.json file. (Overwrites the schedule with the same name)
-func SaveUserSchedule(schedule Schedule) error {
-	path, err := getProjectBasePath()
-	if err != nil {
-		log.Fatal("Error getting project base path")
 		return err
 	}
 
-	// open schedules.json
-	file, err := os.Open(filepath.Join(path, "schedules.json"))
+	data, err := json.Marshal(scheduleList)
 	if err != nil {
-		log.Fatal("Error opening schedules.json")
 		return err
 	}
-	defer file.Close()
-
-	// Read the contents of the file
-	data, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatal("Error reading schedules.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
-
-	// Parse the JSON data into a ScheduleList struct
-	var scheduleList ScheduleList
-	err = json.Unmarshal(data, &scheduleList)
+	// Written to a temporary file first, so a crash never leaves half of the schedules behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
 	if err != nil {
-		log.Fatal("Error parsing schedules.json")
 		return err
 	}
-
-	// Find the schedule with the given name
-	var found bool
-	for i, s := range scheduleList.Schedules {
-		if s.Name == schedule.Name {
-			scheduleList.Schedules[i] = schedule
-			found = true
-			break
-		}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
 	}
-
-	// if the schedule is not found, append it to the list
-	if !found {
-		scheduleList.Schedules = append(scheduleList.Schedules, schedule)
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
 	}
-
-	// Marshal the ScheduleList struct back to JSON
-	newData, err := json.Marshal(scheduleList)
-	if err != nil {
-		log.Fatal("Error marshaling schedules.json")
-		return err
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
 	}
-
-	// Write the new JSON data back to the file
-	err = os.WriteFile(filepath.Join(path, "schedules.json"), newData, 0644)
 	if err != nil {
-		log.Fatal("Error writing schedules.json")
-		return err
+		os.Remove(tmp.Name())
 	}
-
-	return nil
+	return err
 }
 
-// Saves the given ScheduleList to the schedules.json file. (Overwrites the file)
-func SaveUserSchedules(scheduleList ScheduleList) error {
-	path, err := getProjectBasePath()
+// adoptLegacySchedules moves the schedules.json of older versions to path. BeeHub used
+// to run for a single student, so the first user reading their schedules is its owner.
+// The move is a rename, only one user can ever adopt the file.
+func adoptLegacySchedules(path string) (bool, error) {
+	base, err := getProjectBasePath()
 	if err != nil {
-		log.Fatal("Error getting project base path")
-		return err
+		return false, err
 	}
-
-	// Marshal the ScheduleList struct to JSON
-	data, err := json.Marshal(scheduleList)
-	if err != nil {
-		log.Fatal("Error marshaling schedules.json")
-		return err
+	legacy := filepath.Join(base, legacySchedulesFile)
+	if _, err := os.Stat(legacy); errors.Is(err, os.ErrNotExist) {
+		return false, nil
 	}
-
-	// Write the JSON data to the file
-	err = os.WriteFile(filepath.Join(path, "schedules.json"), data, 0644)
-	if err != nil {
-		log.Fatal("Error writing schedules.json")
-		return err
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
 	}
-
-	return nil
+	if err := os.Rename(legacy, path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Another user adopted it in the meantime
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-// Returns the base path of the project.
-func getProjectBasePath() (string, error) {
-	basePath, err := os.Getwd()
+// userSchedulesPath returns the schedules file of a user, the user name must be a plain file name.
+func userSchedulesPath(user string) (string, error) {
+	if user == "" || strings.HasPrefix(user, ".") || strings.ContainsAny(user, `/\:`) {
+		return "", fmt.Errorf("invalid user name %q", user)
+	}
+	path, err := getProjectBasePath()
 	if err != nil {
 		return "", err
 	}
-	return basePath, nil
+	return filepath.Join(path, "schedules", user+".json"), nil
 }
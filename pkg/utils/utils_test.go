@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// inTempDir runs the test in an empty working directory, where the schedules are kept.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestSchedulesOf(t *testing.T) {
+	inTempDir(t)
+	list, err := GetSchedulesOf("ayse")
+	if err != nil || list.Schedules == nil || len(list.Schedules) != 0 {
+		t.Fatalf("GetSchedulesOf(new user) = %+v, %v, want no schedules", list, err)
+	}
+
+	saved := ScheduleList{Schedules: []Schedule{{Name: "Plan A", ECRN: []int{11, 12}, SCRN: []int{}}}}
+	if err := SaveSchedulesOf("ayse", saved); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := GetSchedulesOf("ayse"); err != nil || !reflect.DeepEqual(list, saved) {
+		t.Errorf("GetSchedulesOf() = %+v, %v, want %+v", list, err, saved)
+	}
+	if list, err := GetSchedulesOf("mehmet"); err != nil || len(list.Schedules) != 0 {
+		t.Errorf("GetSchedulesOf(other user) = %+v, %v, want no schedules", list, err)
+	}
+	if entries, _ := os.ReadDir("schedules"); len(entries) != 1 {
+		t.Errorf("%d files in schedules/, want only ayse.json", len(entries))
+	}
+}
+
+func TestSchedulesOfInvalidUser(t *testing.T) {
+	inTempDir(t)
+	for _, user := range []string{"", ".", "..", "../ayse", `a\b`, "a:b", ".hidden"} {
+		if _, err := GetSchedulesOf(user); err == nil {
+			t.Errorf("GetSchedulesOf(%q) accepted the user name", user)
+		}
+		if err := SaveSchedulesOf(user, ScheduleList{}); err == nil {
+			t.Errorf("SaveSchedulesOf(%q) accepted the user name", user)
+		}
+	}
+}
+
+// The schedules.json of older versions goes to the first user, nobody else sees it.
+func TestLegacySchedulesAdopted(t *testing.T) {
+	inTempDir(t)
+	legacy := `{"schedules":[{"name":"Güz","ECRN":[21],"SCRN":[11]}]}`
+	if err := os.WriteFile(legacySchedulesFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ScheduleList{Schedules: []Schedule{{Name: "Güz", ECRN: []int{21}, SCRN: []int{11}}}}
+	if list, err := GetSchedulesOf("ayse"); err != nil || !reflect.DeepEqual(list, want) {
+		t.Fatalf("GetSchedulesOf(first user) = %+v, %v, want %+v", list, err, want)
+	}
+	if _, err := os.Stat(legacySchedulesFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s was not moved: %v", legacySchedulesFile, err)
+	}
+	if list, err := GetSchedulesOf("mehmet"); err != nil || len(list.Schedules) != 0 {
+		t.Errorf("GetSchedulesOf(second user) = %+v, %v, want no schedules", list, err)
+	}
+	if list, err := GetSchedulesOf("ayse"); err != nil || !reflect.DeepEqual(list, want) {
+		t.Errorf("GetSchedulesOf(first user again) = %+v, %v, want %+v", list, err, want)
+	}
+}
+
+// A user who already has schedules keeps them, the legacy file waits for a user without any.
+func TestLegacySchedulesNotMerged(t *testing.T) {
+	inTempDir(t)
+	own := ScheduleList{Schedules: []Schedule{{Name: "Bahar", ECRN: []int{31}, SCRN: []int{}}}}
+	if err := SaveSchedulesOf("ayse", own); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(legacySchedulesFile, []byte(`{"schedules":[]}`), 0644)
+
+	if list, err := GetSchedulesOf("ayse"); err != nil || !reflect.DeepEqual(list, own) {
+		t.Errorf("GetSchedulesOf() = %+v, %v, want %+v", list, err, own)
+	}
+	if _, err := os.Stat(legacySchedulesFile); err != nil {
+		t.Errorf("%s was moved to a user with schedules: %v", legacySchedulesFile, err)
+	}
+}
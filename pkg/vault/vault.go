@@ -0,0 +1,237 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrNotFound     = errors.New("credentials not found")
+	ErrWrongKey     = errors.New("vault cannot be decrypted with this key")
+	ErrNoKeySource  = errors.New("vault needs a passphrase or a key file")
+	errCorruptVault = errors.New("vault file is corrupt")
+)
+
+const (
+	keySize  = 32 // AES-256
+	saltSize = 16
+
+	// argon2id parameters for passphrase derived keys
+	argonTime    = 1
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+)
+
+// Credentials are the ITU login credentials of a user.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Vault keeps credentials encrypted with AES-GCM, in memory and optionally in a file.
+// Plaintext credentials only exist for the duration of a Get call.
+type Vault struct {
+	mu    sync.Mutex
+	aead  cipher.AEAD
+	path  string
+	salt  []byte
+	items map[string][]byte
+}
+
+// file is the on-disk representation of a vault.
+type file struct {
+	Version int               `json:"version"`
+	Salt    []byte            `json:"salt,omitempty"`
+	Items   map[string][]byte `json:"items"`
+}
+
+// Open opens the vault stored at path, creating it on first use. An empty path
+// keeps the vault in memory only.
+//
+// The key is derived from passphrase with argon2id when it is set, otherwise it is
+// read from keyFile, which is created with a random key if it does not exist.
+func Open(path, passphrase, keyFile string) (*Vault, error) {
+	v := &Vault{path: path, items: make(map[string][]byte)}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			var f file
+			if err := json.Unmarshal(data, &f); err != nil {
+				return nil, errCorruptVault
+			}
+			v.salt = f.Salt
+			if f.Items != nil {
+				v.items = f.Items
+			}
+		}
+	}
+
+	var key []byte
+	var err error
+	switch {
+	case passphrase != "":
+		if v.salt == nil {
+			v.salt = make([]byte, saltSize)
+			if _, err := rand.Read(v.salt); err != nil {
+				return nil, err
+			}
+		}
+		key = argon2.IDKey([]byte(passphrase), v.salt, argonTime, argonMemory, argonThreads, keySize)
+	case keyFile != "":
+		v.salt = nil
+		key, err = loadOrCreateKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNoKeySource
+	}
+
+	v.aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the key matches the existing items before anything is written.
+	for id := range v.items {
+		if _, err := v.Get(id); err != nil {
+			return nil, err
+		}
+		break
+	}
+	return v, nil
+}
+
+// NewMemory creates a vault that is never written to disk. Its key is random and
+// lost with the process, together with the credentials.
+func NewMemory() (*Vault, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{aead: aead, items: make(map[string][]byte)}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Put encrypts and stores the credentials under id, replacing any previous ones.
+func (v *Vault) Put(id string, credentials Credentials) error {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	// The id is authenticated so sealed credentials cannot be moved to another entry.
+	sealed := v.aead.Seal(nonce, nonce, plaintext, []byte(id))
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.items[id] = sealed
+	return v.save()
+}
+
+// Get decrypts the credentials stored under id.
+func (v *Vault) Get(id string) (Credentials, error) {
+	v.mu.Lock()
+	sealed, ok := v.items[id]
+	v.mu.Unlock()
+	if !ok {
+		return Credentials{}, ErrNotFound
+	}
+
+	nonceSize := v.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return Credentials{}, errCorruptVault
+	}
+	plaintext, err := v.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(id))
+	if err != nil {
+		return Credentials{}, ErrWrongKey
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return Credentials{}, errCorruptVault
+	}
+	return credentials, nil
+}
+
+// Delete removes the credentials stored under id.
+func (v *Vault) Delete(id string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.items[id]; !ok {
+		return nil
+	}
+	delete(v.items, id)
+	return v.save()
+}
+
+// save must be called with v.mu held.
+func (v *Vault) save() error {
+	if v.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(file{Version: 1, Salt: v.salt, Items: v.items})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a half written vault.
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
+
+// loadOrCreateKey reads a key file, generating a new random key if it does not exist.
+func loadOrCreateKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key file %s must contain %d bytes", path, keySize)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,186 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var student = Credentials{Username: "student@itu.edu.tr", Password: "correct horse"}
+
+func mustOpen(t *testing.T, path, passphrase, keyFile string) *Vault {
+	t.Helper()
+	v, err := Open(path, passphrase, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	memory, err := NewMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaults := map[string]*Vault{
+		"memory":         memory,
+		"key file":       mustOpen(t, filepath.Join(dir, "key.vault"), "", filepath.Join(dir, "vault.key")),
+		"passphrase":     mustOpen(t, filepath.Join(dir, "passphrase.vault"), "secret", ""),
+		"in memory file": mustOpen(t, "", "secret", ""),
+	}
+	for name, v := range vaults {
+		t.Run(name, func(t *testing.T) {
+			if err := v.Put("session-1", student); err != nil {
+				t.Fatal(err)
+			}
+			got, err := v.Get("session-1")
+			if err != nil || got != student {
+				t.Fatalf("Get() = %+v, %v, want %+v", got, err, student)
+			}
+			if _, err := v.Get("session-2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+			}
+			if err := v.Delete("session-1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := v.Get("session-1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(deleted) error = %v, want ErrNotFound", err)
+			}
+			if err := v.Delete("session-1"); err != nil {
+				t.Errorf("Delete(deleted) error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	v, err := NewMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("session-1", student); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("the memory vault wrote %d files", len(entries))
+	}
+}
+
+func TestReopen(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase string
+		// reopen with these instead, empty keeps the ones of the first open
+		reopenPassphrase string
+		reopenKeyFile    string
+		wantErr          error
+	}{
+		{name: "key file"},
+		{name: "passphrase", passphrase: "secret"},
+		{name: "other key file", reopenKeyFile: "other.key", wantErr: ErrWrongKey},
+		{name: "wrong passphrase", passphrase: "secret", reopenPassphrase: "guess", wantErr: ErrWrongKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path, keyFile := filepath.Join(dir, "credentials.vault"), filepath.Join(dir, "vault.key")
+			v := mustOpen(t, path, tt.passphrase, keyFile)
+			if err := v.Put("session-1", student); err != nil {
+				t.Fatal(err)
+			}
+
+			passphrase, reopenKeyFile := tt.passphrase, keyFile
+			if tt.reopenPassphrase != "" {
+				passphrase = tt.reopenPassphrase
+			}
+			if tt.reopenKeyFile != "" {
+				reopenKeyFile = filepath.Join(dir, tt.reopenKeyFile)
+			}
+			reopened, err := Open(path, passphrase, reopenKeyFile)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, err := reopened.Get("session-1"); err != nil || got != student {
+				t.Errorf("Get() = %+v, %v, want %+v", got, err, student)
+			}
+		})
+	}
+}
+
+// The id is authenticated, credentials sealed for one session cannot be used for another.
+func TestSealedEntryBoundToID(t *testing.T) {
+	v, err := NewMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("victim", student); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("attacker", Credentials{Username: "attacker"}); err != nil {
+		t.Fatal(err)
+	}
+	v.items["attacker"] = v.items["victim"]
+
+	if got, err := v.Get("attacker"); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Get(moved entry) = %+v, %v, want ErrWrongKey", got, err)
+	}
+	if got, err := v.Get("victim"); err != nil || got != student {
+		t.Errorf("Get(victim) = %+v, %v, want %+v", got, err, student)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	shortKey := filepath.Join(dir, "short.key")
+	os.WriteFile(shortKey, []byte("too short"), 0600)
+	corrupt := filepath.Join(dir, "corrupt.vault")
+	os.WriteFile(corrupt, []byte("{not json"), 0600)
+
+	tests := []struct {
+		name                      string
+		path, passphrase, keyFile string
+		wantErr                   error
+	}{
+		{name: "no key source", path: filepath.Join(dir, "a.vault"), wantErr: ErrNoKeySource},
+		{name: "corrupt vault", path: corrupt, passphrase: "secret", wantErr: errCorruptVault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Open(tt.path, tt.passphrase, tt.keyFile); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Open("", "", shortKey); err == nil {
+		t.Error("Open() accepted a key file of the wrong size")
+	}
+}
+
+func TestKeyFileCreated(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "beehub", "vault.key")
+	mustOpen(t, "", "", keyFile)
+
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != keySize {
+		t.Errorf("key file size = %d, want %d", info.Size(), keySize)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("key file permissions = %v, want 0600", perm)
+	}
+}